@@ -0,0 +1,48 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/vieolo/shiraz/utils"
+	terminalutils "github.com/vieolo/terminal-utils"
+)
+
+// flakyCmd represents the flaky command
+var flakyCmd = &cobra.Command{
+	Use:   "flaky",
+	Short: "Lists the flaky tests",
+	Long: `Lists the tests with the highest flake rate over the recent runs.
+	The flaky tests are recorded when the tests are run with retries (--retries flag or test.retries in shiraz.json)`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runs := utils.GetFlakyHistory()
+		stats := utils.GetFlakyStats(runs)
+		if len(stats) == 0 {
+			terminalutils.PrintSuccess(fmt.Sprintf("No flaky tests in the last %v run(s)", len(runs)))
+			return
+		}
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit > 0 && len(stats) > limit {
+			stats = stats[:limit]
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "Rate\tFlaky\tRuns\tTest\tPackage")
+		for _, s := range stats {
+			fmt.Fprintf(w, "%.2f%%\t%v\t%v\t%v\t%v\n", s.Rate, s.FlakyCount, s.RunCount, s.Test, s.Package)
+		}
+		w.Flush()
+		fmt.Printf("\nOver the last %v run(s)\n", len(runs))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(flakyCmd)
+	flakyCmd.Flags().Int("limit", 20, "The maximum number of the listed tests")
+}
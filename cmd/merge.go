@@ -0,0 +1,40 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vieolo/shiraz/report"
+	terminalutils "github.com/vieolo/terminal-utils"
+)
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge profile.out...",
+	Short: "Merges the coverage profiles",
+	Long: `Merges the coverage profiles (.out files) of separate runs, e.g. the unit and the integration tests, into a single profile.
+	The counts of the same block are summed in the count and atomic modes, and the highest count is kept in the set mode`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		profiles, err := report.MergeProfiles(args)
+		if err != nil {
+			terminalutils.PrintError(err.Error())
+			os.Exit(1)
+		}
+		if err := report.WriteProfileFile(output, profiles); err != nil {
+			terminalutils.PrintError(err.Error())
+			os.Exit(1)
+		}
+		terminalutils.PrintSuccess(fmt.Sprintf("Merged %v profile(s) into %v", len(args), output))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+	mergeCmd.Flags().StringP("output", "o", "merged.out", "The path of the merged profile")
+}
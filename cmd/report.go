@@ -6,13 +6,16 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"runtime"
 
 	"github.com/spf13/cobra"
 	fm "github.com/vieolo/file-management"
 	"github.com/vieolo/shiraz/browser"
 	"github.com/vieolo/shiraz/report"
 	"github.com/vieolo/shiraz/utils"
+	"github.com/vieolo/shiraz/watch"
 	tu "github.com/vieolo/terminal-utils"
+	"golang.org/x/tools/cover"
 	"strings"
 )
 
@@ -23,55 +26,262 @@ var reportCmd = &cobra.Command{
 	Long:  `This command runs the tests and generate the out file (via standard go tool) and generates a report in the coverage folder`,
 	Run: func(cmd *cobra.Command, args []string) {
 		conf := utils.GetConfigOrDefault()
-
-		projPath := "./..."
-		if conf.ProjectPath != "" && conf.ProjectPath != "." {
-			projPath = conf.ProjectPath
+		report.SetCoverageBands(conf.Coverage.Bands)
+		diffBase, _ := cmd.Flags().GetString("diff")
+		noOpen, _ := cmd.Flags().GetBool("no-open")
+		perTest, _ := cmd.Flags().GetBool("per-test")
+		workers, _ := cmd.Flags().GetInt("workers")
+		profiles, _ := cmd.Flags().GetStringArray("profile")
+		covDirs, _ := cmd.Flags().GetStringArray("covdir")
+		watchMode, _ := cmd.Flags().GetBool("watch")
+		if from, _ := cmd.Flags().GetString("from"); from != "" {
+			profiles = append([]string{from}, profiles...)
 		}
-
-		outPath := fmt.Sprintf("%vcoverage.out", conf.CoverageFolderPath)
-		re := os.RemoveAll(conf.CoverageFolderPath)
-		if re != nil {
-			tu.PrintError(re.Error())
+		if watchMode && len(profiles) > 0 {
+			tu.PrintError("The --from and --profile flags cannot be used with --watch, since the tests are not run")
+			os.Exit(1)
+		}
+		if perTest && len(profiles) > 0 {
+			tu.PrintColorln("The --per-test flag is ignored since the tests are not run", tu.Yellow)
+			perTest = false
 		}
-		fm.CreateDirIfNotExists(conf.CoverageFolderPath, 0777)
-
-		// go test -v -coverpkg=./... -coverprofile=coverage/coverage.out ./...
-		cArgs := []string{
-			"test",
-			"-v",
-			fmt.Sprintf("-coverpkg=%v", projPath),
-			fmt.Sprintf("-coverprofile=%v", outPath),
-			fmt.Sprintf("%v/...", projPath),
+		opts := reportOptions{
+			terminalOnly: noOpen || conf.Report.TerminalOnly,
+			perTest:      perTest,
+			workers:      workers,
+			profiles:     profiles,
+			covDirs:      covDirs,
+		}
+		thresholdsMet, runErr := runReport(conf, opts, diffBase, true)
+		if !watchMode {
+			if runErr != nil {
+				tu.PrintError(runErr.Error())
+				os.Exit(1)
+			}
+			if !thresholdsMet {
+				os.Exit(1)
+			}
+			return
 		}
-		cmdString := strings.Join(cArgs, " ")
-	        fmt.Println(cmdString)
+		if runErr != nil {
+			tu.PrintError(runErr.Error())
+		}
+
+		// The coverage of a file may come from the tests of any package, so the
+		// whole report is regenerated once a package of the project is affected
+		watch.Run(conf.ProjectPath, func(files []string) {
+			pkgs, err := watch.AffectedPackages(conf.ProjectPath, files)
+			if err != nil {
+				tu.PrintError(err.Error())
+				return
+			}
+			if len(pkgs) == 0 {
+				return
+			}
 
-		stdout, stderr, commandErr := tu.RunCommand(tu.CommandConfig{
-			Command: "go",
-			Args:    cArgs,
-			Env:     conf.Env,
+			watch.ClearScreen()
+			fmt.Printf("Changed: %v\n\n", strings.Join(files, ", "))
+			if _, runErr := runReport(conf, opts, diffBase, false); runErr != nil {
+				tu.PrintError(runErr.Error())
+			}
 		})
+	},
+}
 
-		fmt.Println(stdout.String())
-		if len(stderr.String()) > 0 {
-			fmt.Println(stderr.String())
+// runReport generates the report, and the diff report if there is a diff base, and
+// checks the thresholds. The report is opened in the browser if `open` is true and
+// the report is not terminal only
+//
+// It reports whether all the thresholds are met, which is not checked if the
+// report cannot be generated
+func runReport(conf utils.ShirazConfig, opts reportOptions, diffBase string, open bool) (bool, error) {
+	folders, err := generateReport(conf, opts)
+	if err != nil {
+		return false, err
+	}
+
+	page := "/index.html"
+	if diffBase != "" {
+		if err := reportDiff(conf, diffBase, folders, opts.terminalOnly); err != nil {
+			return false, err
 		}
+		page = "/diff.html"
+	}
+
+	if open && !opts.terminalOnly {
+		browser.Open(conf.CoverageFolderPath + page)
+	}
+	return checkThresholds(folders, conf), nil
+}
+
+// reportOptions are the options of a single report generation
+type reportOptions struct {
+	// Skips the HTML files and the browser
+	terminalOnly bool
+	// Runs each top-level test separately to record the tests of each line
+	perTest bool
+	// The maximum number of the tests run in parallel in the per-test mode
+	workers int
+	// The existing `.out` files merged into the report instead of running the tests
+	profiles []string
+	// The GOCOVERDIR folders of the binaries built with `-cover`, merged into the report
+	covDirs []string
+}
 
-		if commandErr != nil {
-			tu.PrintError(commandErr.Error())
+// generateReport runs the tests with coverage, generates the HTML, the Cobertura XML
+// and the LCOV reports in the coverage folder and prints the coverage summary
+//
+// The HTML files are skipped in the terminal only mode. When the options have profiles,
+// the tests are not run and the merged profiles are used instead. The coverage data of
+// the GOCOVERDIR folders of the options is merged into either of them
+func generateReport(conf utils.ShirazConfig, opts reportOptions) ([]report.ReportFolder, error) {
+	projPath := "./..."
+	if conf.ProjectPath != "" && conf.ProjectPath != "." {
+		projPath = conf.ProjectPath
+	}
+
+	// The profiles and the coverage data are read before the coverage folder is
+	// removed, since they may be inside of it
+	var merged []*cover.Profile
+	if len(opts.profiles) > 0 {
+		var mergeErr error
+		merged, mergeErr = report.MergeProfiles(opts.profiles)
+		if mergeErr != nil {
+			return nil, mergeErr
+		}
+	}
+	dataPath := ""
+	if len(opts.covDirs) > 0 {
+		var covErr error
+		dataPath, covErr = convertCovData(conf, opts.covDirs)
+		if covErr != nil {
+			return nil, covErr
 		}
+		defer os.Remove(dataPath)
+	}
 
-		genErr := report.GenHTMLReport(outPath, conf)
-		if genErr != nil {
-			tu.PrintError(genErr.Error())
-			return
+	outPath := fmt.Sprintf("%vcoverage.out", conf.CoverageFolderPath)
+	re := os.RemoveAll(conf.CoverageFolderPath)
+	if re != nil {
+		tu.PrintError(re.Error())
+	}
+	fm.CreateDirIfNotExists(conf.CoverageFolderPath, 0777)
+
+	if len(opts.profiles) > 0 {
+		fmt.Printf("Reading %v\n", strings.Join(opts.profiles, ", "))
+		if writeErr := report.WriteProfileFile(outPath, merged); writeErr != nil {
+			return nil, writeErr
 		}
+	} else {
+		runCoverageTests(conf, projPath, outPath)
+	}
+	if dataPath != "" {
+		if covErr := mergeCovData(dataPath, outPath); covErr != nil {
+			return nil, covErr
+		}
+	}
 
-		browser.Open(conf.CoverageFolderPath + "/index.html")
-	},
+	folders, genErr := report.BuildReport(outPath, conf)
+	if genErr != nil {
+		return nil, genErr
+	}
+	if opts.perTest {
+		cov, perTestErr := runPerTestCoverage(conf, projPath, opts.workers)
+		if perTestErr != nil {
+			return nil, perTestErr
+		}
+		if saveErr := utils.SaveTestCoverage(cov); saveErr != nil {
+			tu.PrintError(saveErr.Error())
+		}
+		report.AttachTestCoverage(folders, cov)
+	}
+
+	if !opts.terminalOnly {
+		report.WriteHTMLReport(outPath, folders, conf)
+	}
+
+	genErr = report.GenCoberturaReport(outPath, conf)
+	if genErr != nil {
+		return nil, genErr
+	}
+	genErr = report.GenLCOVReport(outPath, conf)
+	if genErr != nil {
+		return nil, genErr
+	}
+
+	report.PrintCoverageSummary(folders)
+	return folders, nil
+}
+
+// runCoverageTests runs the tests of the project and writes their coverage profile at the out path
+func runCoverageTests(conf utils.ShirazConfig, projPath string, outPath string) {
+	// go test -v -coverpkg=./... -coverprofile=coverage/coverage.out ./...
+	cArgs := []string{
+		"test",
+		"-v",
+		fmt.Sprintf("-coverpkg=%v", projPath),
+		fmt.Sprintf("-coverprofile=%v", outPath),
+	}
+	if conf.Report.CoverMode != "" {
+		cArgs = append(cArgs, fmt.Sprintf("-covermode=%v", conf.Report.CoverMode))
+	}
+	cArgs = append(cArgs, fmt.Sprintf("%v/...", projPath))
+	cmdString := strings.Join(cArgs, " ")
+	fmt.Println(cmdString)
+
+	stdout, stderr, commandErr := tu.RunCommand(tu.CommandConfig{
+		Command: "go",
+		Args:    cArgs,
+		Env:     conf.Env,
+	})
+
+	fmt.Println(stdout.String())
+	if len(stderr.String()) > 0 {
+		fmt.Println(stderr.String())
+	}
+
+	if commandErr != nil {
+		tu.PrintError(commandErr.Error())
+	}
+}
+
+// reportDiff prints the coverage of the lines changed since the base ref and writes
+// it as `diff.html` in the coverage folder, unless in the terminal only mode
+func reportDiff(conf utils.ShirazConfig, base string, folders []report.ReportFolder, terminalOnly bool) error {
+	outPath := fmt.Sprintf("%vcoverage.out", conf.CoverageFolderPath)
+	files, err := report.BuildDiffReport(outPath, conf, base)
+	if err != nil {
+		return err
+	}
+	report.PrintDiffSummary(base, files)
+
+	if terminalOnly {
+		return nil
+	}
+	return report.WriteDiffHTMLReport(outPath, base, files, folders)
+}
+
+// checkThresholds prints the coverages that are below the minimums of the config
+// and reports whether all the thresholds are met
+func checkThresholds(folders []report.ReportFolder, conf utils.ShirazConfig) bool {
+	t := conf.Coverage.Thresholds
+	if t.Global <= 0 && len(t.Folders) == 0 && len(t.Files) == 0 {
+		return true
+	}
+
+	violations := report.CheckThresholds(folders, conf)
+	report.PrintViolations(violations)
+	return len(violations) == 0
 }
 
 func init() {
 	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().String("diff", "", "Reports the coverage of the lines added or modified since the given git ref, e.g. origin/main")
+	reportCmd.Flags().Bool("per-test", false, "Runs each top-level test separately and shows the tests that executed each line")
+	reportCmd.Flags().Int("workers", runtime.NumCPU(), "The maximum number of the tests run in parallel in the per-test mode")
+	reportCmd.Flags().Bool("no-open", false, "Prints the coverage in the terminal without generating the HTML files or opening the browser")
+	reportCmd.Flags().String("from", "", "Generates the report from an existing coverage profile (.out file) without running the tests")
+	reportCmd.Flags().StringArray("profile", []string{}, "Merges the given coverage profile (.out file) into the report instead of running the tests. Can be repeated")
+	reportCmd.Flags().StringArray("covdir", []string{}, "Merges the coverage data of the given GOCOVERDIR folder (of the binaries built with -cover) into the report. Can be repeated")
+	reportCmd.Flags().Bool("watch", false, "Regenerates the report when the Go files of the project change")
 }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vieolo/shiraz/report"
+	"github.com/vieolo/shiraz/utils"
+	tu "github.com/vieolo/terminal-utils"
+)
+
+// convertCovData converts the raw coverage data of the GOCOVERDIR folders with
+// `go tool covdata textfmt` into a temporary profile and returns its path, which
+// should be removed by the caller
+//
+// The binaries built with `-cover` write their coverage in the GOCOVERDIR folder,
+// e.g. when they are run by the end-to-end tests
+func convertCovData(conf utils.ShirazConfig, dirs []string) (string, error) {
+	for _, d := range dirs {
+		if info, err := os.Stat(d); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("%v is not a coverage data folder", d)
+		}
+	}
+
+	f, err := os.CreateTemp("", "shiraz-covdata-*.out")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	dataPath := f.Name()
+
+	cArgs := []string{
+		"tool",
+		"covdata",
+		"textfmt",
+		fmt.Sprintf("-i=%v", strings.Join(dirs, ",")),
+		fmt.Sprintf("-o=%v", dataPath),
+	}
+	fmt.Println("go " + strings.Join(cArgs, " "))
+
+	_, stderr, err := tu.RunCommand(tu.CommandConfig{
+		Command: "go",
+		Args:    cArgs,
+		Env:     conf.Env,
+	})
+	if err != nil {
+		os.Remove(dataPath)
+		return "", fmt.Errorf("cannot convert the coverage data: %v\n%v", err, stderr.String())
+	}
+	return dataPath, nil
+}
+
+// mergeCovData merges the converted coverage data into the profile at the out path
+func mergeCovData(dataPath string, outPath string) error {
+	// The profile of the tests is missing when they could not be built
+	paths := []string{dataPath}
+	if _, statErr := os.Stat(outPath); statErr == nil {
+		paths = append([]string{outPath}, paths...)
+	}
+	profiles, err := report.MergeProfiles(paths)
+	if err != nil {
+		return err
+	}
+	return report.WriteProfileFile(outPath, profiles)
+}
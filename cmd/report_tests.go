@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/vieolo/shiraz/report"
+	"github.com/vieolo/shiraz/utils"
+	tu "github.com/vieolo/terminal-utils"
+)
+
+var testNameRegex = regexp.MustCompile(`^Test\w*$`)
+
+// runPerTestCoverage runs each top-level test of the project with its own coverage
+// profile, at most `workers` tests at a time, and records the tests of each block
+func runPerTestCoverage(conf utils.ShirazConfig, projPath string, workers int) (utils.TestCoverage, error) {
+	tests, err := listTests(conf, projPath)
+	if err != nil {
+		return utils.TestCoverage{}, err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	dir, err := os.MkdirTemp("", "shiraz-per-test-")
+	if err != nil {
+		return utils.TestCoverage{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Printf("Running %v tests separately with %v workers\n", len(tests), workers)
+
+	profiles := make([]report.TestProfile, len(tests))
+	failed := make([]bool, len(tests))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, test := range tests {
+		wg.Add(1)
+		go func(i int, test utils.TestRef) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			outPath := filepath.Join(dir, fmt.Sprintf("%v.out", i))
+			cArgs := []string{
+				"test",
+				fmt.Sprintf("-coverpkg=%v", projPath),
+				fmt.Sprintf("-coverprofile=%v", outPath),
+				fmt.Sprintf("-run=^%v$", test.Name),
+			}
+			if conf.Report.CoverMode != "" {
+				cArgs = append(cArgs, fmt.Sprintf("-covermode=%v", conf.Report.CoverMode))
+			}
+			cArgs = append(cArgs, test.Package)
+
+			_, _, runErr := tu.RunCommand(tu.CommandConfig{
+				Command: "go",
+				Args:    cArgs,
+				Env:     conf.Env,
+			})
+			// A failed test still writes its profile, so only the missing profiles are skipped
+			if _, statErr := os.Stat(outPath); statErr != nil {
+				failed[i] = true
+				return
+			}
+			if runErr != nil {
+				tu.PrintColorln(fmt.Sprintf("%v.%v failed, its coverage is still recorded", test.Package, test.Name), tu.Yellow)
+			}
+			profiles[i] = report.TestProfile{Test: test, Path: outPath}
+		}(i, test)
+	}
+	wg.Wait()
+
+	collected := make([]report.TestProfile, 0, len(profiles))
+	for i, p := range profiles {
+		if failed[i] {
+			tu.PrintError(fmt.Sprintf("No coverage profile was generated for %v.%v", tests[i].Package, tests[i].Name))
+			continue
+		}
+		collected = append(collected, p)
+	}
+	return report.MergeTestProfiles(collected)
+}
+
+// listTests returns the top-level tests of the packages of the project
+func listTests(conf utils.ShirazConfig, projPath string) ([]utils.TestRef, error) {
+	stdout, stderr, err := tu.RunCommand(tu.CommandConfig{
+		Command: "go",
+		Args:    []string{"test", "-list", "^Test", fmt.Sprintf("%v/...", projPath)},
+		Env:     conf.Env,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the tests: %v\n%v", err, stderr.String())
+	}
+
+	// `go test -list` prints the tests of each package followed by its `ok` line
+	tests := []utils.TestRef{}
+	names := []string{}
+	scanner := bufio.NewScanner(strings.NewReader(stdout.String()))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if testNameRegex.MatchString(line) {
+			names = append(names, line)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "ok" {
+			for _, name := range names {
+				tests = append(tests, utils.TestRef{Package: fields[1], Name: name})
+			}
+		}
+		names = names[:0]
+	}
+	return tests, nil
+}
@@ -4,11 +4,20 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/vieolo/shiraz/output"
+	"github.com/vieolo/shiraz/tui"
 	"github.com/vieolo/shiraz/utils"
+	"github.com/vieolo/shiraz/watch"
 	terminalutils "github.com/vieolo/terminal-utils"
 )
 
@@ -17,31 +26,283 @@ var testCmd = &cobra.Command{
 	Use:   "test",
 	Short: "Runs the tests",
 	Long: `Runs the unit tests using the command provided in the shiraz.json file.
-	If no command is provided, a standard test command is run -> go test -v ./...`,
+	If no command is provided, a standard test command is run -> go test -json ./...`,
 	Run: func(cmd *cobra.Command, args []string) {
 		conf := utils.GetConfigOrDefault()
 
-		stdout, stderr, _ := terminalutils.RunRawCommand(conf.Test.Command)
-
-		if len(stderr.String()) > 0 {
-			fmt.Println(stderr.String())
+		useTui, _ := cmd.Flags().GetBool("tui")
+		if useTui {
+			if !output.UsesJSON(conf.Test.Command) {
+				terminalutils.PrintError("The TUI mode requires a test command with the -json flag")
+				return
+			}
+			tuiErr := tui.Run(conf.Test.Command)
+			if tuiErr != nil {
+				terminalutils.PrintError(tuiErr.Error())
+			}
 			return
 		}
 
-		// if commandErr != nil {
-		// 	terminalutils.PrintError(commandErr.Error())
-		// 	return
-		// }
-
 		outputType := output.PackageName
 		if conf.Test.Output == "testname" {
 			outputType = output.TestName
 		}
 
-		output.ParseTestOutput(stdout.String(), outputType)
+		opts := testRunOptions{
+			command:    conf.Test.Command,
+			outputType: outputType,
+			retries:    conf.Test.Retries,
+			junit:      conf.Test.JUnit,
+		}
+		if cmd.Flags().Changed("retries") {
+			opts.retries, _ = cmd.Flags().GetInt("retries")
+		}
+		if cmd.Flags().Changed("junit") {
+			opts.junit, _ = cmd.Flags().GetString("junit")
+		}
+
+		failedMode, _ := cmd.Flags().GetBool("failed")
+		if failedMode {
+			runFailedTests(opts)
+			return
+		}
+
+		since, _ := cmd.Flags().GetString("since")
+		if since != "" {
+			runImpactedTests(opts, conf.ProjectPath, since)
+			return
+		}
+
+		results := runTestCommand(strings.Fields(conf.Test.Command), outputType)
+		results = finishTestRun(opts, results)
+		recordFailedTests(results, true)
+
+		watchMode, _ := cmd.Flags().GetBool("watch")
+		if !watchMode {
+			return
+		}
+
+		watch.Run(conf.ProjectPath, func(files []string) {
+			pkgs, err := watch.AffectedPackages(conf.ProjectPath, files)
+			if err != nil {
+				terminalutils.PrintError(err.Error())
+				return
+			}
+			if len(pkgs) == 0 {
+				return
+			}
+
+			watch.ClearScreen()
+			fmt.Printf("Changed: %v\n\n", strings.Join(files, ", "))
+			results := runTestCommand(output.TargetCommand(conf.Test.Command, pkgs, ""), outputType)
+			results = finishTestRun(opts, results)
+			recordFailedTests(results, false)
+		})
 	},
 }
 
+// runTestCommand runs the test command and prints the parsed results
+//
+// The JSON events are printed while the tests are running, the other commands
+// are parsed once they finish
+func runTestCommand(args []string, outputType int) []output.SinglePackageResult {
+	if len(args) == 0 {
+		terminalutils.PrintError("The test command is empty")
+		return nil
+	}
+
+	if output.UsesJSON(strings.Join(args, " ")) {
+		results, streamErr := streamTestCommand(args, outputType)
+		if streamErr != nil {
+			terminalutils.PrintError(streamErr.Error())
+		}
+		return results
+	}
+
+	var stdout, stderr bytes.Buffer
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	c.Run()
+
+	if len(stderr.String()) > 0 {
+		fmt.Println(stderr.String())
+		return nil
+	}
+
+	return output.ParseTestOutput(stdout.String(), outputType)
+}
+
+// collectTestResults runs the test command and parses its output without printing it
+func collectTestResults(args []string) []output.SinglePackageResult {
+	var stdout bytes.Buffer
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdout = &stdout
+	c.Run()
+
+	if output.UsesJSON(strings.Join(args, " ")) {
+		return output.CollectTestJSON(stdout.String())
+	}
+	return output.CollectTestOutput(stdout.String())
+}
+
+// testRunOptions are the options of the `test` command, from the config file and the flags
+type testRunOptions struct {
+	command    string
+	outputType int
+	retries    int
+	junit      string
+}
+
+// finishTestRun retries the failed tests, prints the flaky tests and the summary
+// of the run, and writes the JUnit report
+//
+// The results are nil when the command could not run, e.g. due to a build error
+func finishTestRun(opts testRunOptions, results []output.SinglePackageResult) []output.SinglePackageResult {
+	if results == nil {
+		return nil
+	}
+
+	if opts.retries > 0 {
+		results = retryFailedTests(opts.command, results, opts.retries)
+		output.PrintFlaky(results, opts.outputType)
+		recordFlakyRun(results)
+	}
+
+	if opts.junit != "" {
+		if err := output.WriteJUnit(opts.junit, results); err != nil {
+			terminalutils.PrintError(err.Error())
+		}
+	}
+
+	output.PrintSummary(results, opts.outputType)
+	return results
+}
+
+// retryFailedTests re-runs each failed test in isolation up to `retries` times and
+// marks the tests that pass on a retry as flaky
+func retryFailedTests(command string, results []output.SinglePackageResult, retries int) []output.SinglePackageResult {
+	passed := map[string][]string{}
+	for pkg, tests := range output.FailedTests(results) {
+		for _, test := range tests {
+			args := append(output.TargetCommand(command, []string{pkg}, output.RunPattern([]string{test})), "-count=1")
+			for i := 0; i < retries; i++ {
+				if testPassed(collectTestResults(args), pkg, test) {
+					passed[pkg] = append(passed[pkg], test)
+					break
+				}
+			}
+		}
+	}
+	return output.MarkFlaky(results, passed)
+}
+
+func testPassed(results []output.SinglePackageResult, pkg string, test string) bool {
+	for _, res := range results {
+		if res.Name != pkg {
+			continue
+		}
+		failed := output.FailedTests([]output.SinglePackageResult{res})[pkg]
+		return res.IsSuccessful || (len(failed) > 0 && !slices.Contains(failed, test))
+	}
+	return false
+}
+
+// recordFlakyRun adds the flaky tests of the run to the flaky history
+func recordFlakyRun(results []output.SinglePackageResult) {
+	run := utils.FlakyRun{
+		Time:     time.Now(),
+		Packages: []string{},
+		Flaky:    output.FlakyTests(results),
+	}
+	for _, res := range results {
+		run.Packages = append(run.Packages, res.Name)
+	}
+
+	if err := utils.AddFlakyRun(run); err != nil {
+		terminalutils.PrintError(err.Error())
+	}
+}
+
+// runFailedTests re-runs only the tests that failed in the last run, package by package
+func runFailedTests(opts testRunOptions) {
+	state := utils.GetTestState()
+	if len(state.Failed) == 0 {
+		terminalutils.PrintSuccess("There are no failed tests from the last run")
+		return
+	}
+
+	pkgs := make([]string, 0, len(state.Failed))
+	for pkg := range state.Failed {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	results := []output.SinglePackageResult{}
+	for _, pkg := range pkgs {
+		runPattern := ""
+		if len(state.Failed[pkg]) > 0 {
+			runPattern = output.RunPattern(state.Failed[pkg])
+		}
+		results = append(results, runTestCommand(output.TargetCommand(opts.command, []string{pkg}, runPattern), opts.outputType)...)
+	}
+	results = finishTestRun(opts, results)
+	recordFailedTests(results, true)
+}
+
+// recordFailedTests saves the failed tests of the run, so they can be re-run with `--failed`
+//
+// If `replace` is false, only the packages of the results are updated in the saved state
+func recordFailedTests(results []output.SinglePackageResult, replace bool) {
+	if results == nil {
+		return
+	}
+
+	state := utils.GetTestState()
+	if replace {
+		state.Failed = map[string][]string{}
+	}
+	for _, res := range results {
+		delete(state.Failed, res.Name)
+	}
+	for pkg, tests := range output.FailedTests(results) {
+		state.Failed[pkg] = tests
+	}
+
+	if err := utils.SaveTestState(state); err != nil {
+		terminalutils.PrintError(err.Error())
+	}
+}
+
+// streamTestCommand runs the test command and prints its `-json` output as it is produced
+//
+// The stderr of the command is passed through to the terminal. Since Go 1.24, the
+// build errors are reported as events and printed with their packages instead
+func streamTestCommand(args []string, outputType int) ([]output.SinglePackageResult, error) {
+	c := exec.Command(args[0], args[1:]...)
+	c.Stderr = os.Stderr
+	stdout, pipeErr := c.StdoutPipe()
+	if pipeErr != nil {
+		return nil, pipeErr
+	}
+
+	if startErr := c.Start(); startErr != nil {
+		return nil, startErr
+	}
+
+	results := output.StreamTestJSON(stdout, outputType)
+
+	// A failing test also exits with a non-zero code, which is already reported above
+	c.Wait()
+	return results, nil
+}
+
 func init() {
 	rootCmd.AddCommand(testCmd)
+	testCmd.Flags().Bool("tui", false, "Runs the tests in an interactive terminal UI")
+	testCmd.Flags().Int("retries", 0, "Re-runs each failed test up to N times and marks the tests that pass on a retry as flaky")
+	testCmd.Flags().String("junit", "", "Writes the results as a JUnit XML file at the given path")
+	testCmd.Flags().Bool("failed", false, "Re-runs only the tests that failed in the last run")
+	testCmd.Flags().String("since", "", "Runs only the tests affected by the changes since the given git ref, e.g. origin/main")
+	testCmd.Flags().Bool("watch", false, "Re-runs the affected packages when the Go files of the project change")
 }
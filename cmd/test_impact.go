@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vieolo/shiraz/output"
+	"github.com/vieolo/shiraz/utils"
+	"github.com/vieolo/shiraz/watch"
+	terminalutils "github.com/vieolo/terminal-utils"
+)
+
+// runImpactedTests runs only the tests affected by the changes since the git ref
+func runImpactedTests(opts testRunOptions, projectPath string, ref string) {
+	impacted, err := impactedTests(projectPath, ref)
+	if err != nil {
+		terminalutils.PrintError(err.Error())
+		return
+	}
+	if len(impacted) == 0 {
+		terminalutils.PrintSuccess(fmt.Sprintf("No test is affected by the changes since %v", ref))
+		return
+	}
+
+	pkgs := make([]string, 0, len(impacted))
+	for pkg := range impacted {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	results := []output.SinglePackageResult{}
+	for _, pkg := range pkgs {
+		runPattern := ""
+		if len(impacted[pkg]) > 0 {
+			runPattern = output.RunPattern(impacted[pkg])
+			fmt.Printf("%v: %v\n", pkg, strings.Join(impacted[pkg], ", "))
+		} else {
+			fmt.Printf("%v: all tests\n", pkg)
+		}
+		results = append(results, runTestCommand(output.TargetCommand(opts.command, []string{pkg}, runPattern), opts.outputType)...)
+	}
+	results = finishTestRun(opts, results)
+	recordFailedTests(results, false)
+}
+
+// impactedTests returns the tests affected by the changes since the git ref, keyed
+// by the import path of their package. A package with no listed test runs all its tests
+//
+// The changed blocks are matched against the per-test coverage of the last
+// `report --per-test` run, whose line numbers are of the base version. The changes
+// that are not covered by that data (e.g. new files, declarations outside the
+// executed blocks, no saved data at all, or data of another commit than the merge
+// base) fall back to the packages affected through the import graph. A changed test
+// file runs all the tests of its package
+func impactedTests(projectPath string, ref string) (map[string][]string, error) {
+	diffs, err := utils.GitDiff(ref)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := watch.ProjectPackages(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	cov, hasCoverage := utils.GetTestCoverage()
+	if hasCoverage {
+		// The hunks are matched with the lines of the merge base, so the coverage
+		// of any other tree may select the wrong tests
+		mergeBase, mbErr := utils.GitMergeBase(ref)
+		if mbErr != nil {
+			return nil, mbErr
+		}
+		if cov.Commit != mergeBase || cov.Dirty {
+			terminalutils.PrintColorln("The per-test coverage is not of the merge base, the affected packages are run instead. Run `report --per-test` on the merge base to select the tests", terminalutils.Yellow)
+			hasCoverage = false
+		}
+	}
+
+	tests := map[string]map[string]bool{}
+	fullPackages := map[string]bool{}
+	fallbackFiles := []string{}
+
+	for file, d := range diffs {
+		pkg, ok := dirs[filepath.Dir(file)]
+		if !ok {
+			continue
+		}
+		if strings.HasSuffix(file, "_test.go") {
+			fullPackages[pkg] = true
+			continue
+		}
+
+		blocks := cov.Files[pkg+"/"+filepath.Base(file)]
+		if !hasCoverage || d.Removed || blocks == nil {
+			fallbackFiles = append(fallbackFiles, file)
+			continue
+		}
+
+		for _, h := range d.Hunks {
+			if trivialHunk(h) {
+				continue
+			}
+			hit := coveringTests(blocks, hunkOldLines(h))
+			if len(hit) == 0 {
+				fallbackFiles = append(fallbackFiles, file)
+				break
+			}
+			for _, t := range hit {
+				test := cov.Tests[t]
+				if tests[test.Package] == nil {
+					tests[test.Package] = map[string]bool{}
+				}
+				tests[test.Package][test.Name] = true
+			}
+		}
+	}
+
+	if len(fallbackFiles) > 0 {
+		affected, err := watch.AffectedPackages(projectPath, fallbackFiles)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range affected {
+			fullPackages[pkg] = true
+		}
+	}
+
+	impacted := map[string][]string{}
+	for pkg := range fullPackages {
+		impacted[pkg] = nil
+	}
+	for pkg, names := range tests {
+		if fullPackages[pkg] {
+			continue
+		}
+		for name := range names {
+			impacted[pkg] = append(impacted[pkg], name)
+		}
+		sort.Strings(impacted[pkg])
+	}
+	return impacted, nil
+}
+
+// hunkOldLines returns the lines of the base version touched by the hunk. An
+// insertion touches the lines around it
+func hunkOldLines(h utils.DiffHunk) []int {
+	if h.OldCount == 0 {
+		return []int{h.OldStart, h.OldStart + 1}
+	}
+	lines := make([]int, 0, h.OldCount)
+	for i := 0; i < h.OldCount; i++ {
+		lines = append(lines, h.OldStart+i)
+	}
+	return lines
+}
+
+// coveringTests returns the indexes of the tests that executed any of the lines
+func coveringTests(blocks []utils.TestCoverageBlock, lines []int) []int {
+	seen := map[int]bool{}
+	hit := []int{}
+	for _, b := range blocks {
+		for _, l := range lines {
+			if l < b.StartLine || l > b.EndLine {
+				continue
+			}
+			for _, t := range b.Tests {
+				if !seen[t] {
+					seen[t] = true
+					hit = append(hit, t)
+				}
+			}
+			break
+		}
+	}
+	return hit
+}
+
+// trivialHunk reports whether the hunk only changes blank lines and comments
+func trivialHunk(h utils.DiffHunk) bool {
+	for _, l := range h.Lines {
+		text := strings.TrimSpace(l[1:])
+		if text != "" && !strings.HasPrefix(text, "//") {
+			return false
+		}
+	}
+	return true
+}
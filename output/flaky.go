@@ -0,0 +1,90 @@
+package output
+
+import (
+	"fmt"
+	"slices"
+)
+
+// MarkFlaky marks the given failed tests, which passed on a retry, as flaky
+//
+// The flaky tests count as passed, so their parents and their packages pass
+// as well if they have no other failed test
+func MarkFlaky(results []SinglePackageResult, passed map[string][]string) []SinglePackageResult {
+	for i, res := range results {
+		names := passed[res.Name]
+		if len(names) == 0 {
+			continue
+		}
+
+		res.Tests = markFlakyTests(res.Tests, names)
+		res.IsSuccessful = !slices.ContainsFunc(res.Tests, func(t SingleTestResult) bool { return !t.IsSuccessful })
+		results[i] = res
+	}
+	return results
+}
+
+func markFlakyTests(tests []SingleTestResult, names []string) []SingleTestResult {
+	for i, t := range tests {
+		if len(t.Subtests) > 0 {
+			// A parent that failed on its own only passes if it passed on a retry as well
+			own := failedOnItsOwn(t)
+			t.Subtests = markFlakyTests(t.Subtests, names)
+			failedSubtest := slices.ContainsFunc(t.Subtests, func(sub SingleTestResult) bool { return !sub.IsSuccessful })
+			if !t.IsSuccessful && !failedSubtest && (!own || slices.Contains(names, t.Name)) {
+				t.IsSuccessful = true
+				t.IsFlaky = own
+			}
+		} else if !t.IsSuccessful && slices.Contains(names, t.Name) {
+			t.IsSuccessful = true
+			t.IsFlaky = true
+		}
+		tests[i] = t
+	}
+	return tests
+}
+
+// FlakyTests returns the names of the flaky tests of each package
+func FlakyTests(results []SinglePackageResult) map[string][]string {
+	flaky := map[string][]string{}
+	for _, res := range results {
+		for _, t := range flattenTests(res.Tests) {
+			if t.IsFlaky {
+				flaky[res.Name] = append(flaky[res.Name], t.Name)
+			}
+		}
+	}
+	return flaky
+}
+
+// PrintFlaky re-prints the packages with the tests that failed and then passed on a
+// retry, since they are printed as failed before the retries. The flaky tests are
+// marked in the tree of the `TestName` output and listed under the package otherwise
+func PrintFlaky(results []SinglePackageResult, outputType int) {
+	flaky := FlakyTests(results)
+	if len(flaky) == 0 {
+		return
+	}
+
+	fmt.Println("-----------------------")
+	fmt.Println(" ")
+	fmt.Println("Flaky Tests")
+
+	w := newResultWriter()
+	for _, res := range results {
+		if len(flaky[res.Name]) == 0 {
+			continue
+		}
+		printPackage(w, res)
+		if outputType == TestName {
+			printTests(w, res.Tests, "", 0)
+			continue
+		}
+		for _, name := range flaky[res.Name] {
+			fmt.Fprintf(w, "|___ \u001b[33mFLAKY\033[0m\t> %v\t\n", name)
+		}
+	}
+	w.Flush()
+
+	fmt.Println(" ")
+	fmt.Println("-----------------------")
+}
@@ -0,0 +1,41 @@
+package output
+
+import "testing"
+
+func TestMarkFlakyKeepsParentFailure(t *testing.T) {
+	results := MarkFlaky(CollectTestJSON(parentOwnFailureJSON), map[string][]string{
+		"example.com/proj/a": {"TestSibling"},
+	})
+
+	if results[0].IsSuccessful {
+		t.Error("expected the package to stay failed")
+	}
+	for _, test := range results[0].Tests {
+		switch test.Name {
+		case "TestParentOwn":
+			if test.IsSuccessful || test.IsFlaky {
+				t.Error("expected the parent failing on its own to stay failed")
+			}
+		case "TestSibling":
+			if !test.IsSuccessful || !test.IsFlaky {
+				t.Error("expected the retried sibling to be flaky")
+			}
+		}
+	}
+}
+
+func TestMarkFlakyParent(t *testing.T) {
+	results := MarkFlaky(CollectTestJSON(parentOwnFailureJSON), map[string][]string{
+		"example.com/proj/a": {"TestSibling", "TestParentOwn"},
+	})
+	if !results[0].IsSuccessful || !results[0].Tests[0].IsFlaky {
+		t.Error("expected the retried parent to be flaky and the package to pass")
+	}
+
+	results = MarkFlaky(CollectTestJSON(rolledUpFailureJSON), map[string][]string{
+		"example.com/proj/b": {"TestTable/x"},
+	})
+	if !results[0].IsSuccessful || results[0].Tests[0].IsFlaky {
+		t.Error("expected the parent to pass with its flaky subtest without being flaky itself")
+	}
+}
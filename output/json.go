@@ -0,0 +1,191 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// TestEvent is a single event emitted by `go test -json` (see `go doc test2json`)
+type TestEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+	// The package being built in the `build-output` and `build-fail` events, which have no `Package`
+	ImportPath string
+	// The `ImportPath` of the failed build of a failed package
+	FailedBuild string
+}
+
+// UsesJSON reports whether the given test command produces the `test2json` event stream
+func UsesJSON(command string) bool {
+	for _, f := range strings.Fields(command) {
+		if f == "-json" || f == "--json" || f == "-json=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// CollectTestJSON parses the output of `go test -json` without printing it
+func CollectTestJSON(raw string) []SinglePackageResult {
+	c := newEventCollector()
+	ReadEvents(strings.NewReader(raw), c.handle)
+	return c.results
+}
+
+// StreamTestJSON reads the output of `go test -json` while the tests are running
+// and prints each package as soon as it finishes. In the `TestName` output, each
+// top level test is printed as soon as it finishes as well, under the name of its
+// package, and the status line of the package is printed once it finishes
+//
+// The error traces are printed once the reader is exhausted, the summary is printed
+// separately with `PrintSummary`
+func StreamTestJSON(r io.Reader, outputType int) []SinglePackageResult {
+	w := newResultWriter()
+	c := newEventCollector()
+
+	// The package whose name is printed above the streamed tests
+	header := ""
+	if outputType == TestName {
+		c.onTest = func(pkg string, tests []SingleTestResult) {
+			if header != pkg {
+				fmt.Fprintf(w, "\u001b[36m>\033[0m\t%v\n", pkg)
+				w.Flush()
+				header = pkg
+			}
+			printTests(w, tests, "", 0)
+			w.Flush()
+		}
+	}
+	c.onPackage = func(res SinglePackageResult) {
+		printPackage(w, res)
+		w.Flush()
+		header = ""
+	}
+
+	ReadEvents(r, c.handle)
+
+	printTraces(c.traces)
+	return c.results
+}
+
+// ReadEvents decodes the `test2json` events of the reader line by line and passes them to `handle`
+//
+// Lines that are not valid JSON events (e.g. build errors) are skipped
+func ReadEvents(r io.Reader, handle func(e TestEvent)) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+
+		var e TestEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue
+		}
+		handle(e)
+	}
+}
+
+// eventCollector builds the package and test results from the events
+type eventCollector struct {
+	results []SinglePackageResult
+	traces  []TestTrace
+
+	// The events of different packages may be interleaved, so the tests
+	// and their outputs are kept per package until the package finishes
+	units   map[string][]SingleTestResult
+	outputs map[string][]string
+	// The compiler output of each build, keyed by its import path
+	builds map[string][]string
+
+	// Optional callbacks, called when a top level test or a package finishes
+	onTest    func(pkg string, tests []SingleTestResult)
+	onPackage func(res SinglePackageResult)
+}
+
+func newEventCollector() *eventCollector {
+	return &eventCollector{
+		results: []SinglePackageResult{},
+		traces:  []TestTrace{},
+		units:   map[string][]SingleTestResult{},
+		outputs: map[string][]string{},
+		builds:  map[string][]string{},
+	}
+}
+
+func (c *eventCollector) handle(e TestEvent) {
+	if e.Action == "build-output" {
+		c.builds[e.ImportPath] = append(c.builds[e.ImportPath], strings.TrimSuffix(e.Output, "\n"))
+		return
+	}
+
+	if e.Test == "" {
+		switch e.Action {
+		case "pass", "fail":
+			res := SinglePackageResult{
+				IsSuccessful: e.Action == "pass",
+				Name:         e.Package,
+				Time:         fmt.Sprintf("%.3fs", e.Elapsed),
+				Elapsed:      e.Elapsed,
+				Tests:        buildTestTree(c.units[e.Package]),
+				BuildOutput:  c.builds[e.FailedBuild],
+			}
+			// A build may fail several packages, e.g. a broken shared dependency,
+			// so its output is only attached to the first of them
+			delete(c.builds, e.FailedBuild)
+			c.results = append(c.results, res)
+			delete(c.units, e.Package)
+
+			if c.onPackage != nil {
+				c.onPackage(res)
+			}
+		}
+		return
+	}
+
+	key := e.Package + "\t" + e.Test
+	switch e.Action {
+	case "output":
+		c.outputs[key] = append(c.outputs[key], strings.TrimSuffix(e.Output, "\n"))
+	case "pass", "fail", "skip":
+		unit := SingleTestResult{
+			Name:         e.Test,
+			IsSuccessful: e.Action != "fail",
+			IsSkipped:    e.Action == "skip",
+			Time:         fmt.Sprintf("(%.2fs)", e.Elapsed),
+			Elapsed:      e.Elapsed,
+			Output:       c.outputs[key],
+		}
+		if e.Action == "fail" {
+			unit.Traces = ParseTraces(c.outputs[key])
+			c.traces = append(c.traces, unit.Traces...)
+		}
+		c.units[e.Package] = append(c.units[e.Package], unit)
+		delete(c.outputs, key)
+
+		if c.onTest != nil && !strings.Contains(e.Test, "/") {
+			c.onTest(e.Package, buildTestTree(testsOf(c.units[e.Package], e.Test)))
+		}
+	}
+}
+
+// testsOf returns the given test and its subtests from the flat list of tests
+func testsOf(units []SingleTestResult, name string) []SingleTestResult {
+	tests := []SingleTestResult{}
+	for _, u := range units {
+		if u.Name == name || strings.HasPrefix(u.Name, name+"/") {
+			tests = append(tests, u)
+		}
+	}
+	return tests
+}
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as a JUnit XML file, in which each package is
+// a testsuite and each counted test (see `countedTest`) is a testcase
+func WriteJUnit(path string, results []SinglePackageResult) error {
+	doc := junitTestSuites{Suites: []junitTestSuite{}}
+	var total float64 = 0
+
+	for _, res := range results {
+		suite := junitTestSuite{
+			Name:      res.Name,
+			Time:      junitTime(res.Elapsed),
+			TestCases: []junitTestCase{},
+		}
+
+		for _, t := range flattenTests(res.Tests) {
+			// The same tests are counted in the summary
+			if !countedTest(t) {
+				continue
+			}
+			if len(t.Subtests) > 0 {
+				t.Output = ownOutput(t.Output)
+			}
+
+			tc := junitTestCase{
+				Name:      t.Name,
+				ClassName: res.Name,
+				Time:      junitTime(t.Elapsed),
+				SystemOut: strings.Join(t.Output, "\n"),
+			}
+
+			if t.IsSkipped {
+				tc.Skipped = &junitMessage{Message: "Skipped"}
+				suite.Skipped += 1
+			} else if !t.IsSuccessful {
+				tc.Failure = junitFailure(t)
+				suite.Failures += 1
+			} else if t.IsFlaky {
+				tc.SystemOut = strings.TrimSpace(tc.SystemOut + "\nFlaky: the test failed and then passed on a retry")
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+
+		// A package that fails without a failed test (e.g. a build failure)
+		// is reported with a failed testcase, so it is not hidden in the CI
+		if !res.IsSuccessful && suite.Failures == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "[package failed]",
+				ClassName: res.Name,
+				Time:      junitTime(res.Elapsed),
+				Failure:   &junitMessage{Message: "The package failed without a failed test", Body: strings.Join(res.BuildOutput, "\n")},
+			})
+			suite.Failures += 1
+		}
+
+		suite.Tests = len(suite.TestCases)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Skipped += suite.Skipped
+		total += res.Elapsed
+		doc.Suites = append(doc.Suites, suite)
+	}
+	doc.Time = junitTime(total)
+
+	b, err := xml.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), b...), 0666)
+}
+
+// junitFailure builds the failure of a test from its error traces, or from its
+// output if the failure has no trace (e.g. `t.Error`)
+func junitFailure(t SingleTestResult) *junitMessage {
+	if len(t.Traces) == 0 {
+		return &junitMessage{Message: "Failed", Body: strings.Join(t.Output, "\n")}
+	}
+
+	messages := []string{}
+	bodies := []string{}
+	for _, trace := range t.Traces {
+		messages = append(messages, strings.TrimSpace(trace.ErrorName))
+		bodies = append(bodies, fmt.Sprintf(
+			"Error:    %v\nExpected: %v\nActual:   %v\nFile:     %v:%v",
+			strings.TrimSpace(trace.ErrorName),
+			strings.TrimSpace(trace.Expected),
+			strings.TrimSpace(trace.Actual),
+			strings.TrimSpace(trace.FileName),
+			strings.TrimSpace(trace.LineNumber),
+		))
+	}
+
+	return &junitMessage{
+		Message: strings.Join(messages, "; "),
+		Type:    "assertion",
+		Body:    strings.Join(bodies, "\n\n"),
+	}
+}
+
+func junitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
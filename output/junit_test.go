@@ -0,0 +1,32 @@
+package output
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteJUnitCountsMatchSummary(t *testing.T) {
+	for _, raw := range []string{parentOwnFailureJSON, rolledUpFailureJSON} {
+		results := CollectTestJSON(raw)
+		path := filepath.Join(t.TempDir(), "junit.xml")
+		if err := WriteJUnit(path, results); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc junitTestSuites
+		if err := xml.Unmarshal(b, &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		passed, failed, _ := countTests(results)
+		if doc.Failures != failed || doc.Tests != passed+failed {
+			t.Errorf("expected %v tests and %v failures as in the summary, got %v and %v", passed+failed, failed, doc.Tests, doc.Failures)
+		}
+	}
+}
@@ -2,8 +2,11 @@ package output
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 
@@ -22,14 +25,23 @@ type TestTrace struct {
 type SingleTestResult struct {
 	Name         string
 	IsSuccessful bool
+	IsSkipped    bool
+	IsFlaky      bool
 	Time         string
+	Elapsed      float64
+	Output       []string
+	Traces       []TestTrace
+	Subtests     []SingleTestResult
 }
 
 type SinglePackageResult struct {
 	IsSuccessful bool
 	Name         string
 	Time         string
+	Elapsed      float64
 	Tests        []SingleTestResult
+	// The compiler output of the package when its build fails
+	BuildOutput []string
 }
 
 const (
@@ -37,130 +49,199 @@ const (
 	TestName
 )
 
-func ParseTestOutput(raw string, outputType int) {
+// ParseTestOutput parses the verbose (`-v`) text output of `go test` and prints the results
+// and the error traces. The summary is printed separately with `PrintSummary`
+//
+// This parser is only used as a fallback for the custom test commands that do not use `-json`
+func ParseTestOutput(raw string, outputType int) []SinglePackageResult {
+	results, traces := parseTextOutput(raw)
+	printResults(results, traces, outputType)
+	return results
+}
+
+// CollectTestOutput parses the verbose (`-v`) text output of `go test` without printing it
+func CollectTestOutput(raw string) []SinglePackageResult {
+	results, _ := parseTextOutput(raw)
+	return results
+}
+
+// testResultRegex matches the result line of a test, e.g. `--- PASS: TestName (0.00s)`
+var testResultRegex = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([\d.]+)s\)\s*$`)
+
+func parseTextOutput(raw string) ([]SinglePackageResult, []TestTrace) {
 	lines := strings.Split(raw, "\n")
 	units := []SingleTestResult{}
 	traces := []TestTrace{}
 	results := []SinglePackageResult{}
-	packageSuccessCount := 0
-	packageFailCount := 0
-	unitSuccessCount := 0
-	unitFailCount := 0
+
+	// The index of the first trace of the current package
+	traceStart := 0
 
 	for i, l := range lines {
 		splited := strings.Split(l, "\t")
 		if len(splited) == 3 && slices.Contains([]string{"ok", "FAIL"}, strings.TrimSpace(splited[0])) {
 			s := strings.TrimSpace(splited[0]) == "ok"
+			elapsed, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(splited[2]), "s"), 64)
+
+			// The traces are matched with their tests by the name of the test
+			for _, t := range traces[traceStart:] {
+				for k := range units {
+					if units[k].Name == strings.TrimSpace(t.TestName) {
+						units[k].Traces = append(units[k].Traces, t)
+					}
+				}
+			}
+			traceStart = len(traces)
+
 			results = append(results, SinglePackageResult{
 				IsSuccessful: s,
 				Name:         strings.TrimSpace(splited[1]),
 				Time:         strings.TrimSpace(splited[2]),
-				Tests:        units,
+				Elapsed:      elapsed,
+				Tests:        buildTestTree(units),
 			})
 
-			if s {
-				packageSuccessCount += 1
-			} else {
-				packageFailCount += 1
-			}
-
 			units = []SingleTestResult{}
 		} else {
-			if strings.Contains(l, "--- PASS:") || strings.Contains(l, "--- FAIL:") {
-				splited = strings.Split(l, " ")
-				s := splited[1] == "PASS:"
+			if m := testResultRegex.FindStringSubmatch(l); m != nil {
+				elapsed, _ := strconv.ParseFloat(m[3], 64)
 				units = append(units, SingleTestResult{
-					Name:         splited[2],
-					IsSuccessful: s,
-					Time:         splited[3],
+					Name:         m[2],
+					IsSuccessful: m[1] != "FAIL",
+					IsSkipped:    m[1] == "SKIP",
+					Time:         fmt.Sprintf("(%vs)", m[3]),
+					Elapsed:      elapsed,
 				})
-
-				if s {
-					unitSuccessCount += 1
-				} else {
-					unitFailCount += 1
-				}
 			} else {
 				if strings.Contains(l, "Error Trace:") {
-					thisTrace := TestTrace{}
-					thisTrace.FileName = strings.Split(splited[2], ":")[0]
-					thisTrace.LineNumber = strings.Split(splited[2], ":")[1]
-
-					for k := i; k < len(lines); k++ {
-						kp := strings.Split(lines[k], "\t")
-						if len(kp) < 3 {
-							continue
-						}
-						kp1 := strings.TrimSpace(kp[1])
-
-						if kp1 == "Error:" {
-							thisTrace.ErrorName = strings.Replace(kp[2], ":", "", -1)
-						} else if kp1 == "Test:" {
-							thisTrace.TestName = kp[2]
-							break
-						} else if strings.Contains(kp[2], "expected:") {
-							thisTrace.Expected = strings.Split(kp[2], ": ")[1]
-						} else if strings.Contains(kp[2], "actual  :") {
-							thisTrace.Actual = strings.Split(kp[2], ": ")[1]
-						}
-					}
-					traces = append(traces, thisTrace)
-
+					traces = append(traces, parseTrace(lines, i))
 				}
 			}
 		}
 	}
+	return results, traces
+}
+
+// ParseTraces finds and parses all the testify style error traces in the output lines of a test
+func ParseTraces(lines []string) []TestTrace {
+	traces := []TestTrace{}
+	for i, l := range lines {
+		if strings.Contains(l, "Error Trace:") {
+			traces = append(traces, parseTrace(lines, i))
+		}
+	}
+	return traces
+}
+
+// parseTrace reads the testify style error trace that starts at line `i`
+func parseTrace(lines []string, i int) TestTrace {
+	thisTrace := TestTrace{}
+	splited := strings.Split(lines[i], "\t")
+	if len(splited) > 2 {
+		fileParts := strings.Split(splited[2], ":")
+		thisTrace.FileName = fileParts[0]
+		if len(fileParts) > 1 {
+			thisTrace.LineNumber = fileParts[1]
+		}
+	}
+
+	for k := i; k < len(lines); k++ {
+		kp := strings.Split(lines[k], "\t")
+		if len(kp) < 3 {
+			continue
+		}
+		kp1 := strings.TrimSpace(kp[1])
+
+		if kp1 == "Error:" {
+			thisTrace.ErrorName = strings.Replace(kp[2], ":", "", -1)
+		} else if kp1 == "Test:" {
+			thisTrace.TestName = kp[2]
+			break
+		} else if strings.Contains(kp[2], "expected:") {
+			thisTrace.Expected = strings.Split(kp[2], ": ")[1]
+		} else if strings.Contains(kp[2], "actual  :") {
+			thisTrace.Actual = strings.Split(kp[2], ": ")[1]
+		}
+	}
+
+	return thisTrace
+}
+
+// printResults prints the parsed packages and the error traces
+func printResults(results []SinglePackageResult, traces []TestTrace, outputType int) {
+	packageWriter := newResultWriter()
+	for _, res := range results {
+		printPackage(packageWriter, res)
+		if outputType == TestName {
+			printTests(packageWriter, res.Tests, "", 0)
+		}
+	}
+	packageWriter.Flush()
+
+	printTraces(traces)
+}
+
+func newResultWriter() *tabwriter.Writer {
 	const padding = 3
-	packageWriter := tabwriter.NewWriter(
+	return tabwriter.NewWriter(
 		os.Stdout,
 		0,
 		0, padding,
 		' ',
 		0,
 	)
+}
 
-	for _, res := range results {
-		statusText := "✓"
-		statusColor := "\u001b[32m"
-		if !res.IsSuccessful {
-			statusText = "x"
-			statusColor = "\u001b[31m"
-		}
-		fmt.Fprintf(packageWriter, "%v%v\033[0m\t%v\t%v\n", statusColor, statusText, res.Name, res.Time)
-
-		if len(res.Tests) > 0 && outputType == TestName {
-			for _, unit := range res.Tests {
-				statusText := "PASS"
-				statusColor := "\u001b[32m"
-				if !unit.IsSuccessful {
-					statusText = "FAIL"
-					statusColor = "\u001b[31m"
-				}
-				fmt.Fprintf(packageWriter, "|___ %v%v\033[0m\t> %v\t%v\n", statusColor, statusText, unit.Name, unit.Time)
-			}
-		}
+// printPackage prints the status line of a single package
+func printPackage(w io.Writer, res SinglePackageResult) {
+	statusText := "✓"
+	statusColor := "\u001b[32m"
+	if !res.IsSuccessful {
+		statusText = "x"
+		statusColor = "\u001b[31m"
+	}
+	fmt.Fprintf(w, "%v%v\033[0m\t%v\t%v\n", statusColor, statusText, res.Name, res.Time)
+	for _, l := range res.BuildOutput {
+		fmt.Fprintf(w, "\t\u001b[31m%v\033[0m\n", l)
 	}
+}
 
-	packageWriter.Flush()
+func printTraces(traces []TestTrace) {
+	if len(traces) == 0 {
+		return
+	}
 
-	if len(traces) > 0 {
-		fmt.Println("-----------------------")
-		fmt.Println(" ")
-		fmt.Println("Error Traces")
-
-		for _, t := range traces {
-			terminalutils.PrintError(fmt.Sprintf(" - %v -> %v", t.TestName, t.ErrorName))
-			terminalutils.PrintColorln(fmt.Sprintf("\tExpected\t%v", t.Expected), terminalutils.Yellow)
-			terminalutils.PrintError(fmt.Sprintf("\tActual  \t%v", t.Actual))
-			fmt.Printf("\tFile    \t%v\n", t.FileName)
-			fmt.Printf("\tLine    \t%v\n", t.LineNumber)
-			fmt.Println(" ")
-		}
+	fmt.Println("-----------------------")
+	fmt.Println(" ")
+	fmt.Println("Error Traces")
 
+	for _, t := range traces {
+		terminalutils.PrintError(fmt.Sprintf(" - %v -> %v", t.TestName, t.ErrorName))
+		terminalutils.PrintColorln(fmt.Sprintf("\tExpected\t%v", t.Expected), terminalutils.Yellow)
+		terminalutils.PrintError(fmt.Sprintf("\tActual  \t%v", t.Actual))
+		fmt.Printf("\tFile    \t%v\n", t.FileName)
+		fmt.Printf("\tLine    \t%v\n", t.LineNumber)
 		fmt.Println(" ")
-		fmt.Println("-----------------------")
 	}
 
+	fmt.Println(" ")
+	fmt.Println("-----------------------")
+}
+
+// PrintSummary prints the number of the failed packages, or the failed tests in the
+// `TestName` output, and the number of the flaky tests
+func PrintSummary(results []SinglePackageResult, outputType int) {
+	packageSuccessCount := 0
+	packageFailCount := 0
+	for _, res := range results {
+		if res.IsSuccessful {
+			packageSuccessCount += 1
+		} else {
+			packageFailCount += 1
+		}
+	}
+	unitSuccessCount, unitFailCount, flakyCount := countTests(results)
+
 	fmt.Println("--------------------")
 	fmt.Println("Summary")
 	if packageFailCount == 0 {
@@ -172,5 +253,60 @@ func ParseTestOutput(raw string, outputType int) {
 			terminalutils.PrintError(fmt.Sprintf("%v test(s) failed out of %v", unitFailCount, unitFailCount+unitSuccessCount))
 		}
 	}
+	if flakyCount > 0 {
+		terminalutils.PrintColorln(fmt.Sprintf("%v flaky test(s) passed after a retry", flakyCount), terminalutils.Yellow)
+	}
 	fmt.Println(" ")
 }
+
+// countTests returns the number of the passed, the failed and the flaky tests of the
+// results. The skipped tests and the parents that are not counted (see `countedTest`)
+// are left out
+func countTests(results []SinglePackageResult) (int, int, int) {
+	passed, failed, flaky := 0, 0, 0
+	for _, res := range results {
+		for _, unit := range flattenTests(res.Tests) {
+			if unit.IsSkipped || !countedTest(unit) {
+				continue
+			}
+			if unit.IsFlaky {
+				flaky += 1
+			}
+			if unit.IsSuccessful {
+				passed += 1
+			} else {
+				failed += 1
+			}
+		}
+	}
+	return passed, failed, flaky
+}
+
+// printTests prints the tests as an indented tree, each level of subtests
+// is shown with the name relative to its parent
+func printTests(w io.Writer, tests []SingleTestResult, parent string, depth int) {
+	for _, unit := range tests {
+		statusText := "PASS"
+		statusColor := "\u001b[32m"
+		if unit.IsSkipped {
+			statusText = "SKIP"
+			statusColor = "\u001b[33m"
+		} else if unit.IsFlaky {
+			statusText = "FLAKY"
+			statusColor = "\u001b[33m"
+		} else if !unit.IsSuccessful {
+			statusText = "FAIL"
+			statusColor = "\u001b[31m"
+		}
+
+		name := unit.Name
+		if parent != "" {
+			name = strings.TrimPrefix(unit.Name, parent+"/")
+		}
+		fmt.Fprintf(w, "%v|___ %v%v\033[0m\t> %v\t%v\n", strings.Repeat("    ", depth), statusColor, statusText, name, unit.Time)
+
+		if len(unit.Subtests) > 0 {
+			printTests(w, unit.Subtests, unit.Name, depth+1)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package output
+
+import "testing"
+
+func TestParseTextOutputLogLines(t *testing.T) {
+	raw := `=== RUN   TestLog
+    a_test.go:8: --- FAIL:
+    a_test.go:9: --- PASS: not a result
+--- PASS: TestLog (0.01s)
+=== RUN   TestFail
+    --- FAIL: TestFail/sub (0.00s)
+--- FAIL: TestFail (0.02s)
+=== RUN   TestSkip
+--- SKIP: TestSkip (0.00s)
+FAIL
+FAIL	example.com/proj/a	0.030s
+`
+	results, _ := parseTextOutput(raw)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 package, got %v", len(results))
+	}
+
+	tests := results[0].Tests
+	if len(tests) != 3 {
+		t.Fatalf("expected 3 top level tests, got %v", len(tests))
+	}
+	if tests[0].Name != "TestLog" || !tests[0].IsSuccessful || tests[0].Elapsed != 0.01 {
+		t.Errorf("unexpected TestLog result: %+v", tests[0])
+	}
+	if tests[1].Name != "TestFail" || tests[1].IsSuccessful || len(tests[1].Subtests) != 1 {
+		t.Errorf("unexpected TestFail result: %+v", tests[1])
+	}
+	if tests[2].Name != "TestSkip" || !tests[2].IsSkipped {
+		t.Errorf("unexpected TestSkip result: %+v", tests[2])
+	}
+}
+
+// parentOwnFailureJSON has a parent that fails on its own after its passing subtest,
+// and a failing sibling
+const parentOwnFailureJSON = `{"Action":"run","Package":"example.com/proj/a","Test":"TestParentOwn"}
+{"Action":"output","Package":"example.com/proj/a","Test":"TestParentOwn","Output":"=== RUN   TestParentOwn\n"}
+{"Action":"run","Package":"example.com/proj/a","Test":"TestParentOwn/sub"}
+{"Action":"output","Package":"example.com/proj/a","Test":"TestParentOwn/sub","Output":"    --- PASS: TestParentOwn/sub (0.00s)\n"}
+{"Action":"pass","Package":"example.com/proj/a","Test":"TestParentOwn/sub","Elapsed":0}
+{"Action":"output","Package":"example.com/proj/a","Test":"TestParentOwn","Output":"    a_test.go:12: boom\n"}
+{"Action":"output","Package":"example.com/proj/a","Test":"TestParentOwn","Output":"--- FAIL: TestParentOwn (0.00s)\n"}
+{"Action":"fail","Package":"example.com/proj/a","Test":"TestParentOwn","Elapsed":0}
+{"Action":"run","Package":"example.com/proj/a","Test":"TestSibling"}
+{"Action":"output","Package":"example.com/proj/a","Test":"TestSibling","Output":"--- FAIL: TestSibling (0.00s)\n"}
+{"Action":"fail","Package":"example.com/proj/a","Test":"TestSibling","Elapsed":0}
+{"Action":"fail","Package":"example.com/proj/a","Elapsed":0.01}
+`
+
+// rolledUpFailureJSON has a parent that only fails through its subtest
+const rolledUpFailureJSON = `{"Action":"run","Package":"example.com/proj/b","Test":"TestTable"}
+{"Action":"output","Package":"example.com/proj/b","Test":"TestTable","Output":"=== RUN   TestTable\n"}
+{"Action":"run","Package":"example.com/proj/b","Test":"TestTable/x"}
+{"Action":"output","Package":"example.com/proj/b","Test":"TestTable/x","Output":"    a_test.go:20: wrong\n"}
+{"Action":"fail","Package":"example.com/proj/b","Test":"TestTable/x","Elapsed":0}
+{"Action":"output","Package":"example.com/proj/b","Test":"TestTable","Output":"--- FAIL: TestTable (0.00s)\n"}
+{"Action":"fail","Package":"example.com/proj/b","Test":"TestTable","Elapsed":0}
+{"Action":"fail","Package":"example.com/proj/b","Elapsed":0.01}
+`
+
+func TestCountTestsParentFailure(t *testing.T) {
+	passed, failed, _ := countTests(CollectTestJSON(parentOwnFailureJSON))
+	if passed != 1 || failed != 2 {
+		t.Errorf("expected the parent failing on its own to be counted, got %v passed and %v failed", passed, failed)
+	}
+
+	passed, failed, _ = countTests(CollectTestJSON(rolledUpFailureJSON))
+	if passed != 0 || failed != 1 {
+		t.Errorf("expected only the failed subtest to be counted, got %v passed and %v failed", passed, failed)
+	}
+}
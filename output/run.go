@@ -0,0 +1,86 @@
+package output
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RunPattern builds the `-run` regex of `go test` that targets only the given tests
+//
+// A single (sub)test is matched exactly on every level, e.g. `^TestFoo$/^case_1$`.
+// When there are multiple tests, they are matched by their top level test
+// since `go test` matches each level of the subtests separately
+func RunPattern(tests []string) string {
+	if len(tests) == 1 {
+		parts := strings.Split(tests[0], "/")
+		for i, p := range parts {
+			parts[i] = "^" + regexp.QuoteMeta(p) + "$"
+		}
+		return strings.Join(parts, "/")
+	}
+
+	names := []string{}
+	for _, t := range tests {
+		top := regexp.QuoteMeta(strings.Split(t, "/")[0])
+		if !slices.Contains(names, top) {
+			names = append(names, top)
+		}
+	}
+	return "^(" + strings.Join(names, "|") + ")$"
+}
+
+// TargetCommand returns the arguments of the test command that only runs the given packages
+//
+// The flags of the command are kept while its package patterns (e.g. `./...`) and
+// its `-run` flag are replaced. An empty `runPattern` runs all the tests of the packages
+func TargetCommand(command string, packages []string, runPattern string) []string {
+	fields := strings.Fields(command)
+	args := []string{}
+	skipNext := false
+	for i, f := range fields {
+		if skipNext {
+			skipNext = false
+			continue
+		}
+		if f == "-run" {
+			skipNext = true
+			continue
+		}
+		if i > 1 && (strings.HasPrefix(f, "-run=") || strings.HasPrefix(f, ".") || strings.HasSuffix(f, "...")) {
+			continue
+		}
+		args = append(args, f)
+	}
+
+	if runPattern != "" {
+		args = append(args, "-run", runPattern)
+	}
+	return append(args, packages...)
+}
+
+// FailedTests returns the names of the failed tests of each failed package
+//
+// Only the deepest failed subtests are returned, since their parents fail with them.
+// A failed package without any failed test (e.g. a build failure) has an empty list
+func FailedTests(results []SinglePackageResult) map[string][]string {
+	failed := map[string][]string{}
+	for _, res := range results {
+		if res.IsSuccessful {
+			continue
+		}
+
+		tests := []string{}
+		for _, t := range flattenTests(res.Tests) {
+			if t.IsSuccessful || t.IsSkipped {
+				continue
+			}
+			if slices.ContainsFunc(t.Subtests, func(sub SingleTestResult) bool { return !sub.IsSuccessful }) {
+				continue
+			}
+			tests = append(tests, t.Name)
+		}
+		failed[res.Name] = tests
+	}
+	return failed
+}
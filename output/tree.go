@@ -0,0 +1,110 @@
+package output
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// buildTestTree nests the flat list of test results by their names
+//
+// `go test` reports the subtests as `TestFoo/case_1`, which are placed
+// under `TestFoo`. A parent that is not reported (e.g. due to an interrupted
+// run) is created from its children
+func buildTestTree(units []SingleTestResult) []SingleTestResult {
+	tree := []SingleTestResult{}
+	for _, unit := range units {
+		tree = insertTest(tree, unit, strings.Split(unit.Name, "/"), 0)
+	}
+	return rollupTests(tree)
+}
+
+func insertTest(tests []SingleTestResult, unit SingleTestResult, parts []string, depth int) []SingleTestResult {
+	name := strings.Join(parts[:depth+1], "/")
+	i := slices.IndexFunc(tests, func(t SingleTestResult) bool { return t.Name == name })
+	if i == -1 {
+		tests = append(tests, SingleTestResult{Name: name, IsSuccessful: true})
+		i = len(tests) - 1
+	}
+
+	if depth == len(parts)-1 {
+		subtests := tests[i].Subtests
+		tests[i] = unit
+		tests[i].Subtests = append(subtests, unit.Subtests...)
+	} else {
+		tests[i].Subtests = insertTest(tests[i].Subtests, unit, parts, depth+1)
+	}
+
+	return tests
+}
+
+// rollupTests marks the parents with a failed subtest as failed and fills
+// the duration of the parents that were not reported with the sum of their subtests
+func rollupTests(tests []SingleTestResult) []SingleTestResult {
+	for i, t := range tests {
+		if len(t.Subtests) == 0 {
+			continue
+		}
+
+		t.Subtests = rollupTests(t.Subtests)
+		var elapsed float64 = 0
+		for _, sub := range t.Subtests {
+			if !sub.IsSuccessful {
+				t.IsSuccessful = false
+			}
+			elapsed += sub.Elapsed
+		}
+
+		if t.Time == "" {
+			t.Elapsed = elapsed
+			t.Time = fmt.Sprintf("(%.2fs)", elapsed)
+		}
+		tests[i] = t
+	}
+	return tests
+}
+
+// flattenTests returns the tests and all of their nested subtests in a single list
+func flattenTests(tests []SingleTestResult) []SingleTestResult {
+	flat := []SingleTestResult{}
+	for _, t := range tests {
+		flat = append(flat, t)
+		flat = append(flat, flattenTests(t.Subtests)...)
+	}
+	return flat
+}
+
+// failedOnItsOwn reports whether the failed test failed by itself rather than only
+// through its subtests, i.e. with all of its subtests passing or with its own
+// output (e.g. a `t.Error` after its subtests)
+func failedOnItsOwn(t SingleTestResult) bool {
+	if t.IsSuccessful || t.IsSkipped {
+		return false
+	}
+	if len(t.Subtests) == 0 || len(ownOutput(t.Output)) > 0 {
+		return true
+	}
+	return !slices.ContainsFunc(t.Subtests, func(sub SingleTestResult) bool { return !sub.IsSuccessful })
+}
+
+// countedTest reports whether the test is counted in the summary and the JUnit report
+//
+// The status of a parent is rolled up from its subtests, so a parent is only
+// counted when it fails on its own, or when it passes on a retry after failing on its own
+func countedTest(t SingleTestResult) bool {
+	return len(t.Subtests) == 0 || failedOnItsOwn(t) || t.IsFlaky
+}
+
+// ownOutput returns the output lines of a test without the frame lines of
+// `go test`, e.g. `=== RUN` and `--- FAIL:`
+func ownOutput(lines []string) []string {
+	own := []string{}
+	for _, l := range lines {
+		t := strings.TrimSpace(l)
+		if t == "" || strings.HasPrefix(t, "=== ") || testResultRegex.MatchString(t) {
+			continue
+		}
+		own = append(own, l)
+	}
+	return own
+}
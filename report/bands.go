@@ -0,0 +1,113 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vieolo/shiraz/utils"
+)
+
+// The coverage bands used by the HTML files and the terminal summary, sorted by their minimum
+var coverageBands = utils.DefaultCoverageBands()
+
+// SetCoverageBands replaces the coverage bands of the reports
+//
+// The default bands are kept if no band is given
+func SetCoverageBands(bands []utils.CoverageBand) {
+	if len(bands) == 0 {
+		return
+	}
+
+	sorted := make([]utils.CoverageBand, len(bands))
+	copy(sorted, bands)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Min < sorted[j].Min })
+	coverageBands = sorted
+}
+
+// getCoverageClass returns the name of the band of the coverage, which is used
+// as the `coverage-<name>` class in the HTML files
+//
+// A coverage of -1 (nothing to cover) gets the `none` class
+func getCoverageClass(cov float64) string {
+	if cov == -1 {
+		return "none"
+	}
+
+	band := coverageBands[0]
+	for _, b := range coverageBands {
+		if cov >= b.Min {
+			band = b
+		}
+	}
+	return bandClassName(band.Name)
+}
+
+// topBandMin returns the minimum coverage of the highest band
+func topBandMin() float64 {
+	return coverageBands[len(coverageBands)-1].Min
+}
+
+var classNameRegex = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+func bandClassName(name string) string {
+	return classNameRegex.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// coverageBandsCSS returns the CSS rules of the `coverage-<name>` classes of the bands
+func coverageBandsCSS() string {
+	rules := make([]string, 0, len(coverageBands))
+	for _, b := range coverageBands {
+		rules = append(rules, fmt.Sprintf(`
+		.coverage-%v {
+			background-color: %v;
+		}`, bandClassName(b.Name), b.Color))
+	}
+	return strings.Join(rules, "")
+}
+
+// bandTerminalColor returns the 24-bit ANSI color of the band of the coverage,
+// or an empty string if the color of the band is not a hex or an rgb color
+func bandTerminalColor(cov float64) string {
+	class := getCoverageClass(cov)
+	for _, b := range coverageBands {
+		if bandClassName(b.Name) != class {
+			continue
+		}
+		r, g, bl, ok := parseColor(b.Color)
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("\u001b[38;2;%v;%v;%vm", r, g, bl)
+	}
+	return ""
+}
+
+var rgbRegex = regexp.MustCompile(`^rgba?\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)`)
+
+// parseColor parses the `#rgb`, `#rrggbb` and `rgb(r, g, b)` CSS colors
+func parseColor(color string) (int, int, int, bool) {
+	color = strings.TrimSpace(color)
+
+	if m := rgbRegex.FindStringSubmatch(color); m != nil {
+		r, _ := strconv.Atoi(m[1])
+		g, _ := strconv.Atoi(m[2])
+		b, _ := strconv.Atoi(m[3])
+		return r, g, b, true
+	}
+
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if !strings.HasPrefix(color, "#") || len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(v >> 16), int(v >> 8 & 0xff), int(v & 0xff), true
+}
@@ -0,0 +1,141 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/vieolo/shiraz/utils"
+)
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        string             `xml:"line-rate,attr"`
+	BranchRate      string             `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      string             `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity string           `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   string          `xml:"line-rate,attr"`
+	BranchRate string          `xml:"branch-rate,attr"`
+	Complexity string          `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// GenCoberturaReport writes the coverage of the `.out` file as a Cobertura XML
+// file (`coverage.xml`) next to the `.out` file
+//
+// The files and folders in the `ignore` list of the config are skipped
+func GenCoberturaReport(outPath string, conf utils.ShirazConfig) error {
+	profiles, dirs, err := loadProfiles(outPath, conf)
+	if err != nil {
+		return err
+	}
+
+	wd, _ := os.Getwd()
+	doc := coberturaCoverage{
+		BranchRate: "0",
+		Complexity: "0",
+		Version:    "shiraz",
+		Timestamp:  time.Now().UnixMilli(),
+		Sources:    []string{wd},
+		Packages:   []coberturaPackage{},
+	}
+
+	packages := map[string]*coberturaPackage{}
+	packageLines := map[string][2]int{}
+	names := []string{}
+
+	for _, profile := range profiles {
+		file, err := findFile(dirs, profile.FileName)
+		if err != nil {
+			return err
+		}
+
+		filename := file
+		if rel, relErr := filepath.Rel(wd, file); relErr == nil {
+			filename = rel
+		}
+
+		lines := []coberturaLine{}
+		covered := 0
+		for _, l := range profileLines(profile) {
+			lines = append(lines, coberturaLine{Number: l.Number, Hits: l.Hits})
+			if l.Hits > 0 {
+				covered += 1
+			}
+		}
+
+		pkgName := path.Dir(profile.FileName)
+		pkg, ok := packages[pkgName]
+		if !ok {
+			pkg = &coberturaPackage{Name: pkgName, BranchRate: "0", Complexity: "0"}
+			packages[pkgName] = pkg
+			names = append(names, pkgName)
+		}
+		pkg.Classes = append(pkg.Classes, coberturaClass{
+			Name:       path.Base(profile.FileName),
+			Filename:   filename,
+			LineRate:   lineRate(covered, len(lines)),
+			BranchRate: "0",
+			Complexity: "0",
+			Lines:      lines,
+		})
+
+		pl := packageLines[pkgName]
+		packageLines[pkgName] = [2]int{pl[0] + covered, pl[1] + len(lines)}
+		doc.LinesCovered += covered
+		doc.LinesValid += len(lines)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		pkg := packages[name]
+		pkg.LineRate = lineRate(packageLines[name][0], packageLines[name][1])
+		doc.Packages = append(doc.Packages, *pkg)
+	}
+	doc.LineRate = lineRate(doc.LinesCovered, doc.LinesValid)
+
+	b, err := xml.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	content := xml.Header + `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">` + "\n" + string(b)
+	return os.WriteFile(filepath.Join(filepath.Dir(outPath), "coverage.xml"), []byte(content), 0777)
+}
+
+func lineRate(covered int, total int) string {
+	if total == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%.4f", float64(covered)/float64(total))
+}
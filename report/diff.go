@@ -0,0 +1,198 @@
+package report
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vieolo/shiraz/utils"
+	terminalutils "github.com/vieolo/terminal-utils"
+	"golang.org/x/tools/cover"
+)
+
+// DiffLine is a line that is added or modified relative to the base ref
+type DiffLine struct {
+	Number     int
+	Text       string
+	Executable bool
+	Covered    bool
+}
+
+// DiffFile is a changed file with the coverage of its changed lines
+type DiffFile struct {
+	Name string
+	// The path of the file relative to the working directory
+	RelativePath string
+	Lines        []DiffLine
+	Covered      int
+	Total        int
+}
+
+// Coverage returns the percentage of the covered lines among the changed executable lines
+func (f DiffFile) Coverage() float64 {
+	if f.Total == 0 {
+		return -1
+	}
+	return float64(f.Covered) / float64(f.Total) * 100
+}
+
+// BuildDiffReport computes the coverage of the lines that are added or modified
+// relative to the base git ref
+//
+// Only the files in the coverage profile are included, and the files and folders
+// in the `ignore` list of the config are skipped
+func BuildDiffReport(outPath string, conf utils.ShirazConfig, base string) ([]DiffFile, error) {
+	changed, err := utils.GitDiff(base)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, dirs, err := loadProfiles(outPath, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	wd, _ := os.Getwd()
+	files := []DiffFile{}
+	for _, profile := range profiles {
+		file, err := findFile(dirs, profile.FileName)
+		if err != nil {
+			return nil, err
+		}
+		abs, _ := filepath.Abs(file)
+		fileDiff, ok := changed[abs]
+		if !ok || fileDiff.Removed {
+			continue
+		}
+
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("can't read %q: %v", profile.FileName, err)
+		}
+		rel, relErr := filepath.Rel(wd, abs)
+		if relErr != nil {
+			rel = file
+		}
+		files = append(files, diffFile(profile, filepath.ToSlash(rel), strings.Split(string(src), "\n"), fileDiff.AddedLines()))
+	}
+
+	return files, nil
+}
+
+// WriteDiffHTMLReport writes the changed files and their lines as `diff.html` next to the `.out` file
+//
+// The folders are written as `sidebar.js` for the navigation sidebar of the page
+func WriteDiffHTMLReport(outPath string, base string, files []DiffFile, folders []ReportFolder) error {
+	outFolder := filepath.Dir(outPath)
+	if err := writeSidebarScript(outFolder, folders); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outFolder, "diff.html"), []byte(generateDiffHTMLFile(base, files, generateSidebarHTML("diff.html"))), 0777)
+}
+
+func diffFile(profile *cover.Profile, relativePath string, src []string, numbers []int) DiffFile {
+	hits := map[int]int{}
+	for _, l := range profileLines(profile) {
+		hits[l.Number] = l.Hits
+	}
+	// The blocks of the profile span the blank and the comment lines inside them,
+	// which are not counted as executable
+	code := codeLines([]byte(strings.Join(src, "\n")))
+
+	df := DiffFile{Name: profile.FileName, RelativePath: relativePath, Lines: []DiffLine{}}
+	for _, n := range numbers {
+		if n < 1 || n > len(src) {
+			continue
+		}
+
+		count, executable := hits[n]
+		executable = executable && code[n]
+		line := DiffLine{Number: n, Text: src[n-1], Executable: executable, Covered: count > 0}
+
+		if line.Executable {
+			df.Total += 1
+			if line.Covered {
+				df.Covered += 1
+			}
+		}
+		df.Lines = append(df.Lines, line)
+	}
+	return df
+}
+
+// codeLines returns the numbers of the lines of the Go source that have a token
+// other than a comment
+func codeLines(src []byte) map[int]bool {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	// The errors are ignored, the source is already compiled by the tests
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	lines := map[int]bool{}
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.COMMENT {
+			continue
+		}
+
+		start := file.Line(pos)
+		end := start
+		// A raw string can span several lines
+		if tok == token.STRING {
+			end += strings.Count(lit, "\n")
+		}
+		for l := start; l <= end; l++ {
+			lines[l] = true
+		}
+	}
+	return lines
+}
+
+// PrintDiffSummary prints the coverage of the changed lines of each file and the total
+func PrintDiffSummary(base string, files []DiffFile) {
+	fmt.Println("--------------------")
+	fmt.Printf("Coverage of the changes since %v\n", base)
+
+	covered := 0
+	total := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "File\tCovered\tCoverage\tUncovered lines")
+	for _, f := range files {
+		if f.Total == 0 {
+			continue
+		}
+		covered += f.Covered
+		total += f.Total
+
+		uncovered := []string{}
+		for _, l := range f.Lines {
+			if l.Executable && !l.Covered {
+				uncovered = append(uncovered, strconv.Itoa(l.Number))
+			}
+		}
+		fmt.Fprintf(w, "%v\t%v/%v\t%.2f%%\t%v\n", f.Name, f.Covered, f.Total, f.Coverage(), strings.Join(uncovered, ","))
+	}
+	w.Flush()
+
+	if total == 0 {
+		terminalutils.PrintSuccess("No executable lines are changed")
+		return
+	}
+
+	summary := fmt.Sprintf("Total: %v/%v changed lines covered (%.2f%%)", covered, total, float64(covered)/float64(total)*100)
+	if covered == total {
+		terminalutils.PrintSuccess(summary)
+	} else {
+		terminalutils.PrintError(summary)
+	}
+}
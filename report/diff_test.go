@@ -0,0 +1,43 @@
+package report
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func TestDiffFileSkipsBlankAndCommentLines(t *testing.T) {
+	src := strings.Split(`package p
+
+func f() string {
+	// a comment
+
+	/* a block
+	comment */
+	s := `+"`raw\n// not a comment`"+`
+	return s
+}
+`, "\n")
+	profile := &cover.Profile{
+		FileName: "example.com/p/a.go",
+		Mode:     "set",
+		Blocks:   []cover.ProfileBlock{{StartLine: 3, StartCol: 17, EndLine: 11, EndCol: 2, NumStmt: 2, Count: 1}},
+	}
+
+	df := diffFile(profile, "a.go", src, []int{4, 5, 6, 7, 8, 9, 10, 11})
+	executable := []int{}
+	for _, l := range df.Lines {
+		if l.Executable {
+			executable = append(executable, l.Number)
+		}
+	}
+	// The lines of the raw string are executable, even if they look like a comment
+	if !slices.Equal(executable, []int{8, 9, 10, 11}) {
+		t.Errorf("unexpected executable lines %v", executable)
+	}
+	if df.Total != 4 || df.Covered != 4 {
+		t.Errorf("expected 4 of 4 lines covered, got %v of %v", df.Covered, df.Total)
+	}
+}
@@ -0,0 +1,207 @@
+package report
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// funcExtent describes a function or a method in the source file
+type funcExtent struct {
+	name      string
+	startLine int
+	startCol  int
+	endLine   int
+	endCol    int
+}
+
+// findFuncs parses the source of the file and returns the extents of its functions and methods
+func findFuncs(name string, src []byte) ([]funcExtent, error) {
+	fset := token.NewFileSet()
+	parsedFile, err := parser.ParseFile(fset, name, src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := []funcExtent{}
+	ast.Inspect(parsedFile, func(n ast.Node) bool {
+		fn, ok := n.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			return true
+		}
+
+		start := fset.Position(fn.Pos())
+		end := fset.Position(fn.End())
+		funcs = append(funcs, funcExtent{
+			name:      funcName(fn),
+			startLine: start.Line,
+			startCol:  start.Column,
+			endLine:   end.Line,
+			endCol:    end.Column,
+		})
+		return false
+	})
+	return funcs, nil
+}
+
+// funcName returns the name of the function, methods are prefixed with their receiver e.g. `(*T).Name`
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	typ := fn.Recv.List[0].Type
+	star, isStar := typ.(*ast.StarExpr)
+	if isStar {
+		typ = star.X
+	}
+
+	// The type parameters of the generic receivers are dropped
+	switch t := typ.(type) {
+	case *ast.IndexExpr:
+		typ = t.X
+	case *ast.IndexListExpr:
+		typ = t.X
+	}
+
+	ident, ok := typ.(*ast.Ident)
+	if !ok {
+		return fn.Name.Name
+	}
+	if isStar {
+		return fmt.Sprintf("(*%v).%v", ident.Name, fn.Name.Name)
+	}
+	return fmt.Sprintf("%v.%v", ident.Name, fn.Name.Name)
+}
+
+// contains reports whether the block starts inside the function
+func (f funcExtent) contains(b cover.ProfileBlock) bool {
+	if b.StartLine < f.startLine || (b.StartLine == f.startLine && b.StartCol < f.startCol) {
+		return false
+	}
+	if b.StartLine > f.endLine || (b.StartLine == f.endLine && b.StartCol >= f.endCol) {
+		return false
+	}
+	return true
+}
+
+// coverage returns the covered and the total statements of the function
+func (f funcExtent) coverage(profile *cover.Profile) (int64, int64) {
+	var covered, total int64
+	for _, b := range profile.Blocks {
+		if !f.contains(b) {
+			continue
+		}
+		total += int64(b.NumStmt)
+		if b.Count > 0 {
+			covered += int64(b.NumStmt)
+		}
+	}
+	return covered, total
+}
+
+// entryCount returns the count of the first block of the function, which is the
+// number of times the function was called (or 1 in the `set` mode)
+func (f funcExtent) entryCount(profile *cover.Profile) int {
+	for _, b := range profile.Blocks {
+		if f.contains(b) {
+			return b.Count
+		}
+	}
+	return 0
+}
+
+// FunctionCoverage is the coverage of a function or a method of a file
+type FunctionCoverage struct {
+	Name         string
+	Line         int
+	Coverage     float64
+	BlockCovered int64
+	BlockTotal   int64
+}
+
+// functionCoverages returns the coverage of the functions and the methods of the
+// file, in the order of their appearance in the source
+func functionCoverages(name string, src []byte, profile *cover.Profile) ([]FunctionCoverage, error) {
+	funcs, err := findFuncs(name, src)
+	if err != nil {
+		return nil, err
+	}
+
+	coverages := make([]FunctionCoverage, 0, len(funcs))
+	for _, f := range funcs {
+		covered, total := f.coverage(profile)
+		coverages = append(coverages, FunctionCoverage{
+			Name:         f.name,
+			Line:         f.startLine,
+			Coverage:     statementCoverage(covered, total),
+			BlockCovered: covered,
+			BlockTotal:   total,
+		})
+	}
+	return coverages, nil
+}
+
+// folderFunction is a function of a file of the folder or its subfolders
+type folderFunction struct {
+	FunctionCoverage
+	File string
+	// The path of the HTML file of the function relative to the folder
+	Link string
+}
+
+// leastCoveredFunctions returns the functions of the files of the folder and its
+// subfolders with the lowest coverage, skipping the fully covered functions and
+// the functions without statements
+//
+// The functions with the same coverage are sorted by their number of statements,
+// so the larger untested functions come first
+func leastCoveredFunctions(fol ReportFolder, limit int) []folderFunction {
+	funcs := make([]folderFunction, 0)
+	seen := map[string]bool{}
+
+	var collect func(f ReportFolder)
+	collect = func(f ReportFolder) {
+		prefix := "."
+		if f.RelativePath != fol.RelativePath {
+			prefix = strings.TrimPrefix(strings.TrimPrefix(f.RelativePath, fol.RelativePath), "/")
+		}
+		for _, file := range f.Files {
+			if seen[file.Path] {
+				continue
+			}
+			seen[file.Path] = true
+
+			base := path.Base(file.Name)
+			link := fmt.Sprintf("%v/%v", prefix, strings.Replace(base, ".go", ".html", 1))
+			for _, fn := range file.Functions {
+				if fn.BlockTotal == 0 || fn.BlockCovered == fn.BlockTotal {
+					continue
+				}
+				funcs = append(funcs, folderFunction{FunctionCoverage: fn, File: base, Link: link})
+			}
+		}
+		for _, sub := range f.Subfolders {
+			collect(sub)
+		}
+	}
+	collect(fol)
+
+	sort.SliceStable(funcs, func(i, j int) bool {
+		if funcs[i].Coverage != funcs[j].Coverage {
+			return funcs[i].Coverage < funcs[j].Coverage
+		}
+		return funcs[i].BlockTotal > funcs[j].BlockTotal
+	})
+
+	if len(funcs) > limit {
+		funcs = funcs[:limit]
+	}
+	return funcs
+}
@@ -4,12 +4,12 @@ import (
 	"fmt"
 	"html/template"
 	"os"
+	"path"
 	"strings"
 
 	filemanagement "github.com/vieolo/file-management"
 	"github.com/vieolo/shiraz/utils"
 	terminalutils "github.com/vieolo/terminal-utils"
-	"golang.org/x/exp/slices"
 	"golang.org/x/tools/cover"
 )
 
@@ -17,12 +17,24 @@ import (
 //
 // It takes the path of the `.out` file, analyze it, and generate the HTML reports
 func GenHTMLReport(outPath string, conf utils.ShirazConfig) error {
+	folders, err := BuildReport(outPath, conf)
+	if err != nil {
+		return err
+	}
+
+	WriteHTMLReport(outPath, folders, conf)
+	return nil
+}
+
+// BuildReport analyzes the `.out` file and returns the folders of the project
+// with the coverage of their files
+func BuildReport(outPath string, conf utils.ShirazConfig) ([]ReportFolder, error) {
 
 	// Parsing the `.out` file. Each profile is the representation of the analysis of a single file
 	// This function is the default golang function
 	profiles, pErr := cover.ParseProfiles(outPath)
 	if pErr != nil {
-		return pErr
+		return nil, pErr
 	}
 
 	// Preparing the folders
@@ -32,32 +44,26 @@ func GenHTMLReport(outPath string, conf utils.ShirazConfig) error {
 	// actual project. This placement makes it easier for the developer to understand the
 	// degree of the coverage
 	//
-	// Each project has its own coverage percentage which is the average coverage of its files
+	// Each folder has its own coverage percentage which is the ratio of the covered statements
+	// of its files, or the average coverage of its files in the `average` aggregation mode
 	folders := make([]ReportFolder, 0)
 
 	// Getting the directories that will be used in coverage
 	dirs, err := findPkgs(profiles)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, profile := range profiles {
 		fn := profile.FileName
-		if slices.Contains(conf.IgnoreFiles, fn) {
-			continue
-		}
-
-		sp := strings.Split(fn, "/")
-		folN := strings.Join(sp[:len(sp)-1], "/")
-
-		if slices.Contains(conf.IgnoreFolders, folN) {
+		if isIgnored(fn, conf) {
 			continue
 		}
 
 		// Finding the file in the folders
 		file, err := findFile(dirs, fn)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Getting the relative path of the folder of the file
@@ -66,18 +72,33 @@ func GenHTMLReport(outPath string, conf utils.ShirazConfig) error {
 		// Reading the contents of the file and generating an HTML detail
 		src, err := os.ReadFile(file)
 		if err != nil {
-			return fmt.Errorf("can't read %q: %v", fn, err)
+			return nil, fmt.Errorf("can't read %q: %v", fn, err)
 		}
 		var buf strings.Builder
 		err = htmlGen(&buf, src, profile.Boundaries(src))
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		functions, err := functionCoverages(file, src, profile)
+		if err != nil {
+			return nil, err
 		}
 
 		thisFolder, created, index := findOrCreateFolder(folders, relativePath, absolutePath)
+		thisFolder.AveragedCoverage = conf.Coverage.Aggregation == utils.AggregationAverage
 
 		coverage, coveredBlock, totalBlock := percentCovered(profile)
 
+		lineHits := map[int]int{}
+		maxCount := 0
+		for _, l := range profileLines(profile) {
+			lineHits[l.Number] = l.Hits
+			if l.Hits > maxCount {
+				maxCount = l.Hits
+			}
+		}
+
 		thisFolder.AddFile(ReportFile{
 			Name:         fn,
 			Path:         file,
@@ -85,6 +106,10 @@ func GenHTMLReport(outPath string, conf utils.ShirazConfig) error {
 			Coverage:     coverage,
 			BlockCovered: coveredBlock,
 			BlockTotal:   totalBlock,
+			Functions:    functions,
+			Mode:         profile.Mode,
+			LineHits:     lineHits,
+			MaxCount:     maxCount,
 		})
 
 		if created {
@@ -94,13 +119,22 @@ func GenHTMLReport(outPath string, conf utils.ShirazConfig) error {
 		}
 	}
 
-	var baseFolder ReportFolder
-
 	for i := 0; i < len(folders); i++ {
 		folder := folders[i]
 		folder.Subfolders = append(folder.Subfolders, getSubfolders(folder, folders)...)
 		folders[i] = folder
+	}
 
+	return folders, nil
+}
+
+// WriteHTMLReport writes the index and the content HTML files of the folders
+// in the folder of the `.out` file
+//
+// The thresholds of the config are used to filter the items of the index pages
+func WriteHTMLReport(outPath string, folders []ReportFolder, conf utils.ShirazConfig) {
+	var baseFolder ReportFolder
+	for _, folder := range folders {
 		if folder.Name == "" {
 			baseFolder = folder
 		}
@@ -108,13 +142,17 @@ func GenHTMLReport(outPath string, conf utils.ShirazConfig) error {
 
 	// Writing the generated HTML files
 	outFolder := strings.Replace(outPath, "/coverage.out", "", 1)
+	if se := writeSidebarScript(outFolder, folders); se != nil {
+		terminalutils.PrintError(se.Error())
+	}
 	for _, fol := range folders {
 
 		prePath := outFolder + "/" + fol.RelativePath
 		filemanagement.CreateDirIfNotExists(prePath, 0777)
 
 		newFileName := fmt.Sprintf("%v/index.html", prePath)
-		iwe := os.WriteFile(newFileName, []byte(generateIndexHTMLFile(fol)), 0777)
+		sidebar := generateSidebarHTML(path.Join(fol.RelativePath, "index.html"))
+		iwe := os.WriteFile(newFileName, []byte(generateIndexHTMLFile(fol, sidebar, conf)), 0777)
 		if iwe != nil {
 			terminalutils.PrintError(iwe.Error())
 		}
@@ -122,13 +160,13 @@ func GenHTMLReport(outPath string, conf utils.ShirazConfig) error {
 		for _, file := range fol.Files {
 			sp := strings.Split(file.Name, "/")
 
-			newFileName := fmt.Sprintf("%v/%v.html", prePath, strings.Replace(sp[len(sp)-1], ".go", "", 1))
-			we := os.WriteFile(newFileName, []byte(generateContentHTMLFile(baseFolder.AbsolutePath, file)), 0777)
+			pageName := strings.Replace(sp[len(sp)-1], ".go", "", 1) + ".html"
+			newFileName := fmt.Sprintf("%v/%v", prePath, pageName)
+			sidebar := generateSidebarHTML(path.Join(fol.RelativePath, pageName))
+			we := os.WriteFile(newFileName, []byte(generateContentHTMLFile(baseFolder.AbsolutePath, file, sidebar)), 0777)
 			if we != nil {
 				terminalutils.PrintError(we.Error())
 			}
 		}
 	}
-
-	return nil
 }
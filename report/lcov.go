@@ -0,0 +1,90 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vieolo/shiraz/utils"
+)
+
+// GenLCOVReport writes the coverage of the `.out` file as an LCOV tracefile
+// (`lcov.info`) next to the `.out` file
+//
+// Each file gets its function (FN/FNDA) and line (DA) records. The files and
+// folders in the `ignore` list of the config are skipped
+func GenLCOVReport(outPath string, conf utils.ShirazConfig) error {
+	profiles, dirs, err := loadProfiles(outPath, conf)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, profile := range profiles {
+		file, err := findFile(dirs, profile.FileName)
+		if err != nil {
+			return err
+		}
+
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("can't read %q: %v", profile.FileName, err)
+		}
+		funcs, err := findFuncs(file, src)
+		if err != nil {
+			return err
+		}
+
+		sb.WriteString("TN:\n")
+		sb.WriteString(fmt.Sprintf("SF:%v\n", file))
+
+		funcsHit := 0
+		names := lcovFuncNames(funcs)
+		for i, f := range funcs {
+			sb.WriteString(fmt.Sprintf("FN:%v,%v\n", f.startLine, names[i]))
+		}
+		for i, f := range funcs {
+			count := f.entryCount(profile)
+			if count > 0 {
+				funcsHit += 1
+			}
+			sb.WriteString(fmt.Sprintf("FNDA:%v,%v\n", count, names[i]))
+		}
+		sb.WriteString(fmt.Sprintf("FNF:%v\n", len(funcs)))
+		sb.WriteString(fmt.Sprintf("FNH:%v\n", funcsHit))
+
+		lines := profileLines(profile)
+		linesHit := 0
+		for _, l := range lines {
+			if l.Hits > 0 {
+				linesHit += 1
+			}
+			sb.WriteString(fmt.Sprintf("DA:%v,%v\n", l.Number, l.Hits))
+		}
+		sb.WriteString(fmt.Sprintf("LF:%v\n", len(lines)))
+		sb.WriteString(fmt.Sprintf("LH:%v\n", linesHit))
+		sb.WriteString("end_of_record\n")
+	}
+
+	return os.WriteFile(filepath.Join(filepath.Dir(outPath), "lcov.info"), []byte(sb.String()), 0777)
+}
+
+// lcovFuncNames returns the names of the functions for the FN and FNDA records,
+// which must be unique in a file. The names that appear more than once, e.g. the
+// `init` functions, are suffixed with their line, e.g. `init:12`
+func lcovFuncNames(funcs []funcExtent) []string {
+	counts := map[string]int{}
+	for _, f := range funcs {
+		counts[f.name] += 1
+	}
+
+	names := make([]string, len(funcs))
+	for i, f := range funcs {
+		names[i] = f.name
+		if counts[f.name] > 1 {
+			names[i] = fmt.Sprintf("%v:%v", f.name, f.startLine)
+		}
+	}
+	return names
+}
@@ -0,0 +1,31 @@
+package report
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLCOVFuncNamesAreUnique(t *testing.T) {
+	src := []byte(`package p
+
+type T struct{}
+
+func init() {}
+
+func (T) Name() string { return "" }
+
+func init() {}
+
+func Name() string { return "" }
+`)
+	funcs, err := findFuncs("a.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := lcovFuncNames(funcs)
+	expected := []string{"init:5", "T.Name", "init:9", "Name"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
@@ -0,0 +1,107 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"golang.org/x/tools/cover"
+)
+
+// MergeProfiles parses the `.out` files and merges their profiles into a single
+// set of profiles, one for each file
+//
+// The counts of the same block are summed in the `count` and `atomic` modes, and
+// the highest count is kept in the `set` mode. All the files must have the same
+// cover mode
+func MergeProfiles(paths []string) ([]*cover.Profile, error) {
+	mode := ""
+	files := map[string]*cover.Profile{}
+	blocks := map[string]map[cover.ProfileBlock]int{}
+
+	for _, p := range paths {
+		profiles, err := cover.ParseProfiles(p)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %v: %v", p, err)
+		}
+
+		for _, profile := range profiles {
+			if mode == "" {
+				mode = profile.Mode
+			} else if profile.Mode != mode {
+				return nil, fmt.Errorf("cannot merge the %v mode of %v with the %v mode", profile.Mode, p, mode)
+			}
+
+			if _, ok := files[profile.FileName]; !ok {
+				files[profile.FileName] = &cover.Profile{FileName: profile.FileName, Mode: profile.Mode}
+				blocks[profile.FileName] = map[cover.ProfileBlock]int{}
+			}
+
+			fileBlocks := blocks[profile.FileName]
+			for _, b := range profile.Blocks {
+				count := b.Count
+				b.Count = 0
+				if mode == "set" {
+					if current, ok := fileBlocks[b]; !ok || count > current {
+						fileBlocks[b] = count
+					}
+				} else {
+					fileBlocks[b] += count
+				}
+			}
+		}
+	}
+
+	merged := make([]*cover.Profile, 0, len(files))
+	for name, profile := range files {
+		for b, count := range blocks[name] {
+			b.Count = count
+			profile.Blocks = append(profile.Blocks, b)
+		}
+		sort.Slice(profile.Blocks, func(i, j int) bool {
+			bi, bj := profile.Blocks[i], profile.Blocks[j]
+			if bi.StartLine != bj.StartLine {
+				return bi.StartLine < bj.StartLine
+			}
+			if bi.StartCol != bj.StartCol {
+				return bi.StartCol < bj.StartCol
+			}
+			if bi.EndLine != bj.EndLine {
+				return bi.EndLine < bj.EndLine
+			}
+			return bi.EndCol < bj.EndCol
+		})
+		merged = append(merged, profile)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].FileName < merged[j].FileName })
+	return merged, nil
+}
+
+// WriteProfiles writes the profiles in the format of the `.out` files
+func WriteProfiles(w io.Writer, profiles []*cover.Profile) error {
+	mode := "set"
+	if len(profiles) > 0 {
+		mode = profiles[0].Mode
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "mode: %v\n", mode)
+	for _, p := range profiles {
+		for _, b := range p.Blocks {
+			fmt.Fprintf(bw, "%v:%v.%v,%v.%v %v %v\n", p.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt, b.Count)
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteProfileFile writes the profiles as a `.out` file at the out path
+func WriteProfileFile(outPath string, profiles []*cover.Profile) error {
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return WriteProfiles(f, profiles)
+}
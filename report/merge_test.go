@@ -0,0 +1,97 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProfile(t *testing.T, name string, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// mergedCounts returns the merged count of the blocks of `a.go` by their start line
+func mergedCounts(t *testing.T, paths ...string) map[int]int {
+	t.Helper()
+	profiles, err := MergeProfiles(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[int]int{}
+	for _, p := range profiles {
+		if p.FileName != "example.com/p/a.go" {
+			continue
+		}
+		for _, b := range p.Blocks {
+			counts[b.StartLine] = b.Count
+		}
+	}
+	return counts
+}
+
+func TestMergeProfilesSetKeepsMax(t *testing.T) {
+	a := writeProfile(t, "a.out", "mode: set\nexample.com/p/a.go:1.1,2.2 1 1\nexample.com/p/a.go:3.1,4.2 1 0\nexample.com/p/a.go:5.1,6.2 1 0\n")
+	b := writeProfile(t, "b.out", "mode: set\nexample.com/p/a.go:1.1,2.2 1 0\nexample.com/p/a.go:3.1,4.2 1 1\nexample.com/p/a.go:5.1,6.2 1 0\n")
+
+	counts := mergedCounts(t, a, b)
+	if len(counts) != 3 {
+		t.Fatalf("expected 3 blocks, got %v", counts)
+	}
+	if counts[1] != 1 || counts[3] != 1 || counts[5] != 0 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+}
+
+func TestMergeProfilesCountSums(t *testing.T) {
+	for _, mode := range []string{"count", "atomic"} {
+		a := writeProfile(t, "a.out", "mode: "+mode+"\nexample.com/p/a.go:1.1,2.2 1 2\nexample.com/p/a.go:3.1,4.2 1 0\n")
+		b := writeProfile(t, "b.out", "mode: "+mode+"\nexample.com/p/a.go:1.1,2.2 1 3\nexample.com/p/a.go:3.1,4.2 1 0\nexample.com/p/b.go:1.1,2.2 1 4\n")
+
+		profiles, err := MergeProfiles([]string{a, b})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(profiles) != 2 || profiles[0].FileName != "example.com/p/a.go" || profiles[0].Mode != mode {
+			t.Fatalf("unexpected profiles in the %v mode", mode)
+		}
+		counts := mergedCounts(t, a, b)
+		if counts[1] != 5 || counts[3] != 0 {
+			t.Errorf("unexpected counts in the %v mode: %v", mode, counts)
+		}
+		if len(profiles[0].Blocks) != 2 {
+			t.Errorf("expected the zero count block to be kept in the %v mode", mode)
+		}
+	}
+}
+
+func TestMergeProfilesModeMismatch(t *testing.T) {
+	a := writeProfile(t, "a.out", "mode: set\nexample.com/p/a.go:1.1,2.2 1 1\n")
+	b := writeProfile(t, "b.out", "mode: count\nexample.com/p/a.go:1.1,2.2 1 3\n")
+
+	if _, err := MergeProfiles([]string{a, b}); err == nil {
+		t.Error("expected an error for the different cover modes")
+	}
+}
+
+func TestWriteProfiles(t *testing.T) {
+	a := writeProfile(t, "a.out", "mode: count\nexample.com/p/a.go:3.1,4.2 1 0\nexample.com/p/a.go:1.1,2.2 2 7\n")
+	profiles, err := MergeProfiles([]string{a})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	if err := WriteProfiles(&sb, profiles); err != nil {
+		t.Fatal(err)
+	}
+	expected := "mode: count\nexample.com/p/a.go:1.1,2.2 2 7\nexample.com/p/a.go:3.1,4.2 1 0\n"
+	if sb.String() != expected {
+		t.Errorf("unexpected output:\n%v", sb.String())
+	}
+}
@@ -9,6 +9,14 @@ type ReportFile struct {
 	Coverage     float64
 	BlockCovered int64
 	BlockTotal   int64
+	Functions    []FunctionCoverage
+	// The cover mode of the profile, one of `set`, `count` and `atomic`
+	Mode string
+	// The execution count of each line with a statement and the highest count of the file
+	LineHits map[int]int
+	MaxCount int
+	// The tests that executed each line, only set in the per-test mode
+	LineTests map[int][]string
 }
 
 func (f *ReportFolder) AddFile(p ReportFile) []ReportFile {
@@ -23,8 +31,11 @@ type ReportFolder struct {
 	Coverage     float64
 	BlockCovered int64
 	BlockTotal   int64
-	Subfolders   []ReportFolder
-	Files        []ReportFile
+	// When true, the coverage of the folder is the average of the coverage
+	// percentages of its files instead of the ratio of its covered statements
+	AveragedCoverage bool
+	Subfolders       []ReportFolder
+	Files            []ReportFile
 }
 
 type ReportFolderCoverage struct {
@@ -33,9 +44,88 @@ type ReportFolderCoverage struct {
 	Files          float64
 	Folders        float64
 	TotalFileCount int
+	// The covered and the total statements of the files of the folder and its subfolders
+	BlockCovered int64
+	BlockTotal   int64
 }
 
+// GetCoverage returns the coverage of the folder, its files and its subfolders
+//
+// By default, the coverages are the ratio of the covered statements to the
+// total statements. If `AveragedCoverage` is set, they are the average of the
+// coverage percentages of the files and the subfolders
 func (f ReportFolder) GetCoverage() ReportFolderCoverage {
+	if f.AveragedCoverage {
+		return f.averagedCoverage()
+	}
+
+	var filesCovered, filesTotal int64
+	for _, file := range f.Files {
+		filesCovered += file.BlockCovered
+		filesTotal += file.BlockTotal
+	}
+
+	seen := map[string]bool{}
+	for _, file := range f.Files {
+		seen[file.Path] = true
+	}
+
+	folCoverage := float64(-1)
+	var folCovered, folTotal int64
+	if len(f.Subfolders) > 0 {
+		for _, sub := range f.Subfolders {
+			c, t := sub.statements(seen)
+			folCovered += c
+			folTotal += t
+		}
+		folCoverage = statementCoverage(folCovered, folTotal)
+	}
+
+	filesCoverage := float64(-1)
+	if len(f.Files) > 0 {
+		filesCoverage = statementCoverage(filesCovered, filesTotal)
+	}
+
+	covered := filesCovered + folCovered
+	total := filesTotal + folTotal
+	return ReportFolderCoverage{
+		Files:          filesCoverage,
+		Total:          statementCoverage(covered, total),
+		Folders:        folCoverage,
+		TotalFileCount: len(seen),
+		BlockCovered:   covered,
+		BlockTotal:     total,
+	}
+}
+
+// statements returns the covered and the total statements of the files of the
+// folder and its subfolders, skipping the files that are already seen
+func (f ReportFolder) statements(seen map[string]bool) (int64, int64) {
+	var covered, total int64
+	for _, file := range f.Files {
+		if seen[file.Path] {
+			continue
+		}
+		seen[file.Path] = true
+		covered += file.BlockCovered
+		total += file.BlockTotal
+	}
+	for _, sub := range f.Subfolders {
+		c, t := sub.statements(seen)
+		covered += c
+		total += t
+	}
+	return covered, total
+}
+
+func statementCoverage(covered int64, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) / float64(total) * 100
+}
+
+func (f ReportFolder) averagedCoverage() ReportFolderCoverage {
 
 	var total float64 = 0
 	var fileCount int = 0
@@ -46,7 +136,11 @@ func (f ReportFolder) GetCoverage() ReportFolderCoverage {
 		total += file.Coverage
 		fileCount += 1
 	}
-	filesCoverage = filesCoverage / float64(len(f.Files))
+	if len(f.Files) > 0 {
+		filesCoverage = filesCoverage / float64(len(f.Files))
+	} else {
+		filesCoverage = -1
+	}
 
 	var folCoverage float64 = 0
 	if len(f.Subfolders) > 0 {
@@ -61,13 +155,59 @@ func (f ReportFolder) GetCoverage() ReportFolderCoverage {
 		folCoverage = -1
 	}
 
+	covered, statements := f.statements(map[string]bool{})
 	return ReportFolderCoverage{
 		Files:          filesCoverage,
 		Total:          total / float64(fileCount),
 		UndividedTotal: total,
 		Folders:        folCoverage,
 		TotalFileCount: fileCount,
+		BlockCovered:   covered,
+		BlockTotal:     statements,
+	}
+}
+
+// ProjectCoverage returns the total coverage of the project
+//
+// If the base folder of the project has no files, the coverage is
+// calculated from the top level folders
+func ProjectCoverage(folders []ReportFolder) float64 {
+	var total float64 = 0
+	var fileCount int = 0
+	var blockCovered, blockTotal int64
+	averaged := false
+	for _, f := range folders {
+		if f.Name == "" {
+			return f.GetCoverage().Total
+		}
+		if isTopLevelFolder(f, folders) {
+			c := f.GetCoverage()
+			total += c.UndividedTotal
+			fileCount += c.TotalFileCount
+			blockCovered += c.BlockCovered
+			blockTotal += c.BlockTotal
+			averaged = f.AveragedCoverage
+		}
+	}
+
+	if !averaged {
+		return statementCoverage(blockCovered, blockTotal)
+	}
+	if fileCount == 0 {
+		return 0
+	}
+	return total / float64(fileCount)
+}
+
+func isTopLevelFolder(f ReportFolder, folders []ReportFolder) bool {
+	for _, other := range folders {
+		for _, sub := range other.Subfolders {
+			if sub.RelativePath == f.RelativePath {
+				return false
+			}
+		}
 	}
+	return true
 }
 
 type Pkg struct {
@@ -0,0 +1,44 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"text/tabwriter"
+)
+
+// PrintCoverageSummary prints the coverage of each folder and its files as a table,
+// followed by the total coverage of the project
+//
+// The coverages are colored with the same bands as the HTML report
+func PrintCoverageSummary(folders []ReportFolder) {
+	sorted := make([]ReportFolder, len(folders))
+	copy(sorted, folders)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].RelativePath < sorted[j].RelativePath })
+
+	var covered, total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Folder/File\tStatements\tCoverage")
+	for _, fol := range sorted {
+		name := fol.RelativePath
+		if name == "" {
+			name = "."
+		}
+		c := fol.GetCoverage()
+		fmt.Fprintf(w, "%v/\t%v/%v\t%v\n", name, c.BlockCovered, c.BlockTotal, coloredCoverage(c.Total))
+
+		for _, file := range fol.Files {
+			covered += file.BlockCovered
+			total += file.BlockTotal
+			fmt.Fprintf(w, "    %v\t%v/%v\t%v\n", path.Base(file.Name), file.BlockCovered, file.BlockTotal, coloredCoverage(file.Coverage))
+		}
+	}
+	fmt.Fprintf(w, "Total\t%v/%v\t%v\n", covered, total, coloredCoverage(ProjectCoverage(folders)))
+	w.Flush()
+}
+
+// coloredCoverage returns the percentage colored by its coverage band
+func coloredCoverage(cov float64) string {
+	return fmt.Sprintf("%v%.2f%%\033[0m", bandTerminalColor(cov), cov)
+}
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+)
+
+// syntaxToken is a highlighted part of the source, from `start` (inclusive) to `end` (exclusive)
+type syntaxToken struct {
+	start int
+	end   int
+	class string
+}
+
+// syntaxTokens scans the Go source and returns its keywords, identifiers, literals
+// and comments, in the order of their appearance
+//
+// The `tok-*` classes of the tokens are styled by the content HTML files
+func syntaxTokens(src []byte) []syntaxToken {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	// The errors are ignored, the source is already compiled by the tests
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	tokens := make([]syntaxToken, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		class := ""
+		switch {
+		case tok == token.COMMENT:
+			class = "tok-com"
+		case tok == token.STRING || tok == token.CHAR:
+			class = "tok-str"
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = "tok-num"
+		case tok == token.IDENT:
+			class = "tok-id"
+		case tok.IsKeyword():
+			class = "tok-kw"
+		default:
+			continue
+		}
+
+		start := file.Offset(pos)
+		end := start + len(lit)
+		if tok.IsKeyword() {
+			end = start + len(tok.String())
+		}
+		// The scanner drops the carriage returns of the comments and the raw strings,
+		// so their ends are found in the source
+		if tok == token.COMMENT || (tok == token.STRING && src[start] == '`') {
+			end = literalEnd(src, start)
+		}
+		if end > start {
+			tokens = append(tokens, syntaxToken{start: start, end: end, class: class})
+		}
+	}
+	return tokens
+}
+
+// literalEnd returns the end of the comment or the raw string that starts at the offset
+func literalEnd(src []byte, start int) int {
+	closing := []byte("`")
+	opening := 1
+	if bytes.HasPrefix(src[start:], []byte("//")) {
+		closing = []byte("\n")
+		opening = 2
+	} else if bytes.HasPrefix(src[start:], []byte("/*")) {
+		closing = []byte("*/")
+		opening = 2
+	}
+
+	// The opening of the literal is skipped, so a raw string is not closed by its own backtick
+	i := bytes.Index(src[start+opening:], closing)
+	if i == -1 {
+		return len(src)
+	}
+	end := start + opening + i
+	if closing[0] != '\n' {
+		end += len(closing)
+	}
+	return end
+}
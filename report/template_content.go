@@ -2,24 +2,56 @@ package report
 
 import (
 	"fmt"
+	"html"
+	"math"
+	"strconv"
 	"strings"
 )
 
 // This function takes the analyzed body of a file and insert it into the
 // final HTML file to be saved in to the drive
-func generateContentHTMLFile(basePath string, file ReportFile) string {
+func generateContentHTMLFile(basePath string, file ReportFile, sidebar string) string {
 
 	name := strings.TrimPrefix(strings.Replace(file.Path, basePath, "", 1), "/")
 
 	// Adding line number to the `pre` tags
+	// The line numbers are anchored so the functions can link to their lines
 	n := strings.Split(string(file.Body), "\n")
+	width := testsWidth(file)
 	for i := 0; i < len(n); i++ {
-		n[i] = fmt.Sprintf("%v    %v", i+1, n[i])
+		n[i] = fmt.Sprintf(`<span id="L%v">%v</span>%v%v    %v`, i+1, i+1, hitsGutter(file, i+1), testsGutter(file, i+1, width), n[i])
 	}
 	f := strings.Join(n, "\n")
 
 	coverageClass := getCoverageClass(file.Coverage)
 
+	functions := make([]string, 0)
+	for _, fn := range file.Functions {
+		functions = append(functions, fmt.Sprintf(`
+		<tr>
+			<td class="func-td"><a href="#L%v">%v</a></td>
+			<td class="statements-td">%v/%v</td>
+			<td class="coverage-text coverage-%v">%.2f%%</td>
+		</tr>
+		`, fn.Line, html.EscapeString(fn.Name), fn.BlockCovered, fn.BlockTotal, getCoverageClass(fn.Coverage), fn.Coverage))
+	}
+
+	funcTable := ""
+	if len(functions) > 0 {
+		funcTable = fmt.Sprintf(`
+		<table>
+			<tbody>
+				<tr>
+					<td class="func-td">Functions</td>
+					<td class="statements-td">Statements</td>
+					<td>Coverage</td>
+				</tr>
+				%v
+			</tbody>
+		</table>
+		`, strings.Join(functions, ""))
+	}
+
 	temp := fmt.Sprintf(`
 	<html>
 
@@ -57,15 +89,18 @@ func generateContentHTMLFile(basePath string, file ReportFile) string {
 			color: black;
 			padding: 2px 5px;
 		}
-		.coverage-error {
-			background-color: rgb(229, 85, 85);			
+		table {
+			width: 100%%;
+			margin: 10px 0;
+			border-bottom: 1px solid rgb(113, 113, 113);
 		}
-		.coverage-alert {
-			background-color: rgb(220, 207, 104);
+		.func-td {
+			width: 350px;
 		}
-		.coverage-success {
-			background-color: rgb(57, 220, 57);
+		.statements-td {
+			width: 150px;
 		}
+		%v
 		#topbar {
 			background: black;
 			position: fixed;
@@ -89,34 +124,185 @@ func generateContentHTMLFile(basePath string, file ReportFile) string {
 		#legend span {
 			margin: 0 5px;
 		}
-		.cov0 { color: rgb(192, 0, 0) }
-		.cov1 { color: rgb(128, 128, 128) }
-		.cov2 { color: rgb(116, 140, 131) }
-		.cov3 { color: rgb(104, 152, 134) }
-		.cov4 { color: rgb(92, 164, 137) }
-		.cov5 { color: rgb(80, 176, 140) }
-		.cov6 { color: rgb(68, 188, 143) }
-		.cov7 { color: rgb(56, 200, 146) }
-		.cov8 { color: rgb(44, 212, 149) }
-		.cov9 { color: rgb(32, 224, 152) }
-		.cov10 { color: rgb(20, 236, 155) }
+		.legend {
+			display: flex;
+			align-items: center;
+			column-gap: 8px;
+			font-size: 12px;
+		}
+		.hits {
+			color: rgb(80, 80, 80);
+		}
+		.tests {
+			color: rgb(124, 152, 255);
+			cursor: pointer;
+		}
+		#tests-panel {
+			display: none;
+			position: fixed;
+			right: 10px;
+			bottom: 10px;
+			max-width: 500px;
+			max-height: 50%%;
+			overflow: auto;
+			padding: 10px;
+			background: rgb(20, 20, 20);
+			border: 1px solid rgb(80, 80, 80);
+			color: rgb(200, 200, 200);
+		}
+		/* The coverage is shown by the background, so the syntax colors can be shown by the text */
+		.cov0 { color: rgb(192, 0, 0); background-color: rgba(192, 0, 0, 0.2) }
+		.cov1 { color: rgb(128, 128, 128); background-color: rgba(128, 128, 128, 0.2) }
+		.cov2 { color: rgb(116, 140, 131); background-color: rgba(116, 140, 131, 0.2) }
+		.cov3 { color: rgb(104, 152, 134); background-color: rgba(104, 152, 134, 0.2) }
+		.cov4 { color: rgb(92, 164, 137); background-color: rgba(92, 164, 137, 0.2) }
+		.cov5 { color: rgb(80, 176, 140); background-color: rgba(80, 176, 140, 0.2) }
+		.cov6 { color: rgb(68, 188, 143); background-color: rgba(68, 188, 143, 0.2) }
+		.cov7 { color: rgb(56, 200, 146); background-color: rgba(56, 200, 146, 0.2) }
+		.cov8 { color: rgb(44, 212, 149); background-color: rgba(44, 212, 149, 0.2) }
+		.cov9 { color: rgb(32, 224, 152); background-color: rgba(32, 224, 152, 0.2) }
+		.cov10 { color: rgb(20, 236, 155); background-color: rgba(20, 236, 155, 0.2) }
+		.tok-kw { color: rgb(198, 120, 221) }
+		.tok-id { color: rgb(171, 178, 191) }
+		.tok-str { color: rgb(152, 195, 121) }
+		.tok-num { color: rgb(209, 154, 102) }
+		.tok-com { color: rgb(110, 118, 129); font-style: italic }
 
 	</style>
 		</head>
 
 		<body>
+			%v
 			<div class="file-name">
 				<a href="./index.html"><-</a>
 				<p>%v</p>
 			</div>
 			<div class="coverage-header">				
 				<p class="coverage-text coverage-%v">Coverage: %.2f%%</p>
+				%v
 			</div>
+			%v
 			<pre>%v
 			</pre>
+			%v
 		</body>
 	</html>
-	`, name, coverageClass, file.Coverage, f)
+	`, coverageBandsCSS(), sidebar, name, coverageClass, file.Coverage, heatmapLegend(file), funcTable, f, testsPanel(file))
 
 	return temp
 }
+
+// hitsGutter returns the execution count of the line, padded to the width of the
+// highest count, when the profile counts the executions (`count` and `atomic` modes)
+func hitsGutter(file ReportFile, line int) string {
+	if file.Mode == "set" || file.Mode == "" {
+		return ""
+	}
+
+	width := len(strconv.Itoa(file.MaxCount))
+	hits, ok := file.LineHits[line]
+	if !ok {
+		return fmt.Sprintf(`  <span class="hits">%v</span>`, strings.Repeat(" ", width))
+	}
+
+	class := "hits"
+	if hits == 0 {
+		class = "cov0"
+	}
+	return fmt.Sprintf(`  <span class="%v">%*v</span>`, class, width, hits)
+}
+
+// heatmapLegend returns the legend of the `cov0`-`cov10` classes of the lines
+//
+// In the `set` mode, the lines are either covered or not. In the `count` and
+// `atomic` modes, each class covers a range of the execution counts. The ranges
+// follow the logarithmic scale of the normalized counts of the profile
+func heatmapLegend(file ReportFile) string {
+	items := []string{`<span class="cov0">not covered</span>`}
+	if file.Mode == "set" || file.Mode == "" || file.MaxCount <= 1 {
+		items = append(items, `<span class="cov8">covered</span>`)
+		return fmt.Sprintf(`<p class="legend">%v</p>`, strings.Join(items, ""))
+	}
+
+	// The class `n` holds the counts whose normalized value is in [(n-1)/9, n/9)
+	bound := func(n int) int {
+		return int(math.Ceil(math.Pow(float64(file.MaxCount), float64(n)/9)))
+	}
+	for n := 1; n <= 10; n++ {
+		low := bound(n - 1)
+		high := bound(n) - 1
+		// Only the highest count gets the normalized value of 1
+		if n == 10 {
+			low = file.MaxCount
+			high = file.MaxCount
+		}
+		if low > high {
+			continue
+		}
+
+		label := fmt.Sprintf("%v-%v", low, high)
+		if low == high {
+			label = strconv.Itoa(low)
+		}
+		items = append(items, fmt.Sprintf(`<span class="cov%v">%v</span>`, n, label))
+	}
+	return fmt.Sprintf(`<p class="legend">hits: %v</p>`, strings.Join(items, ""))
+}
+
+// testsWidth returns the width of the test counts of the gutter, which is the
+// number of the digits of the highest count of the file
+func testsWidth(file ReportFile) int {
+	width := 1
+	for _, tests := range file.LineTests {
+		if w := len(strconv.Itoa(len(tests))); w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// testsGutter returns the number of the tests that executed the line in the
+// per-test mode, padded to the width of the file. The names of the tests are
+// shown on hover and on click
+func testsGutter(file ReportFile, line int, width int) string {
+	if file.LineTests == nil {
+		return ""
+	}
+
+	tests, ok := file.LineTests[line]
+	if !ok {
+		return fmt.Sprintf("  %v ", strings.Repeat(" ", width))
+	}
+	names := html.EscapeString(strings.Join(tests, "\n"))
+	return fmt.Sprintf(`  <span class="tests" title="%v" data-line="%v">%*vt</span>`, names, line, width, len(tests))
+}
+
+// testsPanel returns the panel that lists the tests of the clicked line in the per-test mode
+func testsPanel(file ReportFile) string {
+	if file.LineTests == nil {
+		return ""
+	}
+	return `
+	<div id="tests-panel"></div>
+	<script>
+	(function () {
+		var panel = document.getElementById("tests-panel");
+		document.querySelectorAll(".tests").forEach(function (el) {
+			el.addEventListener("click", function () {
+				panel.textContent = "";
+				var title = document.createElement("p");
+				title.textContent = "Tests of line " + el.getAttribute("data-line");
+				panel.appendChild(title);
+				el.getAttribute("title").split("\n").forEach(function (name) {
+					var item = document.createElement("div");
+					item.textContent = name;
+					panel.appendChild(item);
+				});
+				panel.style.display = "block";
+			});
+		});
+		panel.addEventListener("click", function () { panel.style.display = "none"; });
+	})();
+	</script>
+	`
+}
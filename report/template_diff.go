@@ -0,0 +1,117 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// This function takes the changed files and their lines and generates
+// the HTML page of the diff coverage
+func generateDiffHTMLFile(base string, files []DiffFile, sidebar string) string {
+
+	covered := 0
+	total := 0
+	sections := make([]string, 0)
+	for _, f := range files {
+		covered += f.Covered
+		total += f.Total
+
+		lines := make([]string, 0)
+		for _, l := range f.Lines {
+			class := "cov1"
+			if l.Executable && l.Covered {
+				class = "cov8"
+			} else if l.Executable {
+				class = "cov0"
+			}
+			lines = append(lines, fmt.Sprintf(`<span class="%v">%v    %v</span>`, class, l.Number, html.EscapeString(l.Text)))
+		}
+
+		coverageText := "No executable lines"
+		coverageClass := "none"
+		if f.Total > 0 {
+			coverageText = fmt.Sprintf("%v/%v: %.2f%%", f.Covered, f.Total, f.Coverage())
+			coverageClass = getCoverageClass(f.Coverage())
+		}
+
+		sections = append(sections, fmt.Sprintf(`
+		<div class="coverage-header">
+			<a href="./%v">%v</a>
+			<p class="coverage-text coverage-%v">%v</p>
+		</div>
+		<pre>%v</pre>
+		`, strings.Replace(f.RelativePath, ".go", ".html", 1), f.Name, coverageClass, coverageText, strings.Join(lines, "\n")))
+	}
+
+	totalCoverage := 100.0
+	if total > 0 {
+		totalCoverage = float64(covered) / float64(total) * 100
+	}
+
+	temp := fmt.Sprintf(`
+	<html>
+
+		<head>
+		<style>
+		body {
+			background: rgb(29, 29, 29);
+			color: rgb(113, 113, 113);
+		}
+		body, pre, #legend span {
+			font-family: Menlo, monospace;
+			font-weight: bold;
+		}
+		a {
+			color: rgb(124, 152, 255);
+			text-decoration: none;
+		}
+		.file-name {
+			display: flex;
+			align-items: center;
+			column-gap: 10px;
+		}
+		.file-name p {
+			margin: 0;
+			font-size: 12px;
+		}
+		.coverage-header {
+			height: 40px;
+			display: flex;
+			align-items: center;
+			column-gap: 10px;
+			border-bottom: 1px solid rgb(113, 113, 113);
+		}
+		.coverage-text {
+			color: black;
+			padding: 2px 5px;
+		}
+		%v
+		.coverage-none {
+			display: none;
+		}
+		.cov0 { color: rgb(192, 0, 0) }
+		.cov1 { color: rgb(128, 128, 128) }
+		.cov8 { color: rgb(44, 212, 149) }
+
+	</style>
+		</head>
+
+		<body>
+			%v
+			<div class="file-name">
+				<a href="./index.html"><-</a>
+				<p>Changes since %v</p>
+			</div>
+			<div class="coverage-header">
+				<p>Coverage -> </p>
+				<p class="coverage-text coverage-%v">Changed lines: %v/%v: %.2f%%</p>
+			</div>
+
+			%v
+		</body>
+	</html>
+	`, coverageBandsCSS(), sidebar, html.EscapeString(base), getCoverageClass(totalCoverage), covered, total, totalCoverage, strings.Join(sections, ""))
+
+	return temp
+}
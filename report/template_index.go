@@ -2,12 +2,16 @@ package report
 
 import (
 	"fmt"
+	"html"
+	"path"
 	"strings"
+
+	"github.com/vieolo/shiraz/utils"
 )
 
 // This function takes the analyzed body of a file and insert it into the
 // final HTML file to be saved in to the drive
-func generateIndexHTMLFile(fol ReportFolder) string {
+func generateIndexHTMLFile(fol ReportFolder, sidebar string, conf utils.ShirazConfig) string {
 
 	folderCoverage := fol.GetCoverage()
 
@@ -15,6 +19,12 @@ func generateIndexHTMLFile(fol ReportFolder) string {
 	folFilesCC := getCoverageClass(folderCoverage.Files)
 	folFoldersCC := getCoverageClass(folderCoverage.Folders)
 
+	// The items are filtered by their thresholds in the config, or by the top coverage band
+	belowLabel := fmt.Sprintf("Only below %v%%", topBandMin())
+	if hasThresholds(conf) {
+		belowLabel = "Only below the thresholds"
+	}
+
 	backButton := ""
 	if fol.Name != "" {
 		backButton = `<a href="../index.html"><-</a>`
@@ -26,38 +36,74 @@ func generateIndexHTMLFile(fol ReportFolder) string {
 		name := sp[len(sp)-1]
 		fileCoverageClass := getCoverageClass(f.Coverage)
 		files = append(files, fmt.Sprintf(`
-		<tr>	
+		<tr data-name="%v" data-statements="%v" data-coverage="%.2f" data-minimum="%v">	
 			<td class="file-td"><a href="./%v">%v</a></td>
+			<td class="statements-td">%v/%v</td>
 			<td class="coverage-text coverage-%v">%.2f%%</td>
 		</tr>
-		`, strings.Replace(name, ".go", ".html", 1), name, fileCoverageClass, f.Coverage))
+		`, name, f.BlockTotal, f.Coverage, fileMinimum(conf, fol, f), strings.Replace(name, ".go", ".html", 1), name, f.BlockCovered, f.BlockTotal, fileCoverageClass, f.Coverage))
 	}
 
 	subFolders := make([]string, 0)
 	for _, sub := range fol.Subfolders {
-		cc := getCoverageClass(sub.GetCoverage().Total)
+		subCoverage := sub.GetCoverage()
+		cc := getCoverageClass(subCoverage.Total)
 		subFolders = append(subFolders, fmt.Sprintf(`
-		<tr>
-			<td class="file-td"><a href="%v/index.html">%v</a></td>
+		<tr data-name="%v" data-statements="%v" data-coverage="%.2f" data-minimum="%v">
+			<td class="file-td"><a href="%v">%v</a></td>
+			<td class="statements-td">%v/%v</td>
 			<td class="coverage-text coverage-%v">%.2f%%</td>
 		</tr>
-		`, sub.RelativePath, sub.Name, cc, sub.GetCoverage().Total))
+		`, sub.RelativePath, subCoverage.BlockTotal, subCoverage.Total, folderMinimum(conf, sub), relativeLink(fol.RelativePath, path.Join(sub.RelativePath, "index.html")), sub.Name, subCoverage.BlockCovered, subCoverage.BlockTotal, cc, subCoverage.Total))
 	}
 
 	subTable := ""
 	if len(subFolders) > 0 {
 		subTable = fmt.Sprintf(`
+		<table class="sortable">
+			<thead>
+				<tr>
+					<th class="file-td" data-sort="name">Subfolders</th>
+					<th class="statements-td" data-sort="statements">Statements</th>
+					<th data-sort="coverage">Coverage</th>
+				</tr>
+			</thead>
+			<tbody>
+				%v
+			</tbody>
+		</table>
+		<br/>
+		`, strings.Join(subFolders, ""))
+	}
+
+	leastCovered := make([]string, 0)
+	for _, fn := range leastCoveredFunctions(fol, 10) {
+		leastCovered = append(leastCovered, fmt.Sprintf(`
+		<tr>
+			<td class="file-td"><a href="%v#L%v">%v</a></td>
+			<td class="file-td">%v</td>
+			<td class="statements-td">%v/%v</td>
+			<td class="coverage-text coverage-%v">%.2f%%</td>
+		</tr>
+		`, fn.Link, fn.Line, html.EscapeString(fn.Name), fn.File, fn.BlockCovered, fn.BlockTotal, getCoverageClass(fn.Coverage), fn.Coverage))
+	}
+
+	funcTable := ""
+	if len(leastCovered) > 0 {
+		funcTable = fmt.Sprintf(`
+		<br/>
 		<table>
 			<tbody>
 				<tr>
-					<td class="file-td" >Subfolders</td>
+					<td class="file-td">Least covered functions</td>
+					<td class="file-td">File</td>
+					<td class="statements-td">Statements</td>
 					<td>Coverage</td>
 				</tr>
 				%v
 			</tbody>
 		</table>
-		<br/>
-		`, strings.Join(subFolders, ""))
+		`, strings.Join(leastCovered, ""))
 	}
 
 	temp := fmt.Sprintf(`
@@ -85,6 +131,36 @@ func generateIndexHTMLFile(fol ReportFolder) string {
 			width: 350px;
 		}
 
+		.statements-td {
+			width: 150px;
+		}
+
+		th {
+			text-align: left;
+			cursor: pointer;
+			user-select: none;
+		}
+		th[data-order="asc"]::after {
+			content: " \25B2";
+		}
+		th[data-order="desc"]::after {
+			content: " \25BC";
+		}
+		.toolbar {
+			display: flex;
+			align-items: center;
+			column-gap: 20px;
+			margin: 10px 0;
+		}
+		.toolbar input[type="text"] {
+			width: 350px;
+			background: rgb(45, 45, 45);
+			color: rgb(200, 200, 200);
+			border: 1px solid rgb(80, 80, 80);
+			padding: 4px 6px;
+			font-family: Menlo, monospace;
+		}
+
 		.file-name {
 			display: flex;
 			align-items: center;
@@ -106,15 +182,7 @@ func generateIndexHTMLFile(fol ReportFolder) string {
 			color: black;
 			padding: 2px 5px;
 		}
-		.coverage-error {
-			background-color: rgb(229, 85, 85);			
-		}
-		.coverage-alert {
-			background-color: rgb(220, 207, 104);
-		}
-		.coverage-success {
-			background-color: rgb(57, 220, 57);
-		}
+		%v
 		.coverage-none {
 			display: none;
 		}
@@ -157,33 +225,96 @@ func generateIndexHTMLFile(fol ReportFolder) string {
 		</head>
 
 		<body>
+			%v
 			<div class="file-name">
 				%v
 				<p>%v</p>
 			</div>
 			<div class="coverage-header">				
 				<p>Coverage -> </p>
-				<p class="coverage-text coverage-%v">Total: %.2f%%</p>
+				<p class="coverage-text coverage-%v">Total: %v/%v (%.2f%%)</p>
 				<p class="coverage-text coverage-%v">Files: %.2f%%</p>
 				<p class="coverage-text coverage-%v">Folders: %.2f%%</p>
 			</div>
 
+			<div class="toolbar">
+				<input id="search" type="text" placeholder="Search files and folders" />
+				<label><input id="below-threshold" type="checkbox" /> %v</label>
+			</div>
+
 			%v
 
-			<table>
-				<tbody>
-					
+			<table class="sortable">
+				<thead>
 					<tr>
-						<td>Files</td>
-						<td>Coverage</td>
+						<th data-sort="name">Files</th>
+						<th class="statements-td" data-sort="statements">Statements</th>
+						<th data-sort="coverage">Coverage</th>
 					</tr>
-					
+				</thead>
+				<tbody>
 					%v
 				</tbody>
 			</table>
+
+			%v
+
+			%v
 		</body>
 	</html>
-	`, backButton, fol.Name, folTotalCC, folderCoverage.Total, folFilesCC, folderCoverage.Files, folFoldersCC, folderCoverage.Folders, subTable, strings.Join(files, ""))
+	`, coverageBandsCSS(), sidebar, backButton, fol.Name, folTotalCC, folderCoverage.BlockCovered, folderCoverage.BlockTotal, folderCoverage.Total, folFilesCC, folderCoverage.Files, folFoldersCC, folderCoverage.Folders, belowLabel, subTable, strings.Join(files, ""), funcTable, indexScript)
 
 	return temp
 }
+
+// The script of the index pages to sort the tables by clicking on their headers,
+// to search the files and folders by name and to show only the items below their
+// minimum coverage. It is inlined so the pages work from `file://`
+const indexScript = `
+<script>
+(function () {
+	var search = document.getElementById("search");
+	var below = document.getElementById("below-threshold");
+	var tables = document.querySelectorAll("table.sortable");
+
+	function filter() {
+		var query = search.value.toLowerCase();
+		tables.forEach(function (table) {
+			table.querySelectorAll("tbody tr").forEach(function (row) {
+				var name = row.getAttribute("data-name").toLowerCase();
+				var coverage = parseFloat(row.getAttribute("data-coverage"));
+				var minimum = parseFloat(row.getAttribute("data-minimum"));
+				var visible = name.indexOf(query) !== -1 && (!below.checked || coverage < minimum);
+				row.style.display = visible ? "" : "none";
+			});
+		});
+	}
+
+	function sort(table, th) {
+		var key = th.getAttribute("data-sort");
+		var tbody = table.querySelector("tbody");
+		var rows = Array.prototype.slice.call(tbody.querySelectorAll("tr"));
+		var asc = th.getAttribute("data-order") !== "asc";
+
+		table.querySelectorAll("th").forEach(function (h) { h.removeAttribute("data-order"); });
+		th.setAttribute("data-order", asc ? "asc" : "desc");
+
+		rows.sort(function (a, b) {
+			var x = a.getAttribute("data-" + key);
+			var y = b.getAttribute("data-" + key);
+			var c = key === "name" ? x.localeCompare(y) : parseFloat(x) - parseFloat(y);
+			return asc ? c : -c;
+		});
+		rows.forEach(function (row) { tbody.appendChild(row); });
+	}
+
+	tables.forEach(function (table) {
+		table.querySelectorAll("th[data-sort]").forEach(function (th) {
+			th.addEventListener("click", function () { sort(table, th); });
+		});
+	});
+	search.addEventListener("input", filter);
+	below.addEventListener("change", filter);
+})();
+</script>
+`
@@ -0,0 +1,204 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sidebarNode is a folder of the report hierarchy. The intermediate folders
+// without any file have no `folder`
+type sidebarNode struct {
+	name     string
+	path     string
+	folder   *ReportFolder
+	children map[string]*sidebarNode
+}
+
+// buildSidebarTree arranges the folders of the report as a tree by their relative paths
+func buildSidebarTree(folders []ReportFolder) *sidebarNode {
+	root := &sidebarNode{children: map[string]*sidebarNode{}}
+	for i := range folders {
+		fol := &folders[i]
+		node := root
+		if fol.RelativePath != "" {
+			for _, part := range strings.Split(fol.RelativePath, "/") {
+				child, ok := node.children[part]
+				if !ok {
+					child = &sidebarNode{name: part, path: path.Join(node.path, part), children: map[string]*sidebarNode{}}
+					node.children[part] = child
+				}
+				node = child
+			}
+		}
+		node.folder = fol
+	}
+	return root
+}
+
+// writeSidebarScript writes `sidebar.js` in the coverage folder, which renders the
+// collapsible tree of the whole report in the sidebar of every page
+//
+// The tree is built once for the whole report, so its size does not grow with the
+// number of the pages. The links of the tree are relative to the coverage folder
+// and are resolved on each page from the `data-root` of the sidebar
+func writeSidebarScript(outFolder string, folders []ReportFolder) error {
+	root := buildSidebarTree(folders)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`<div class="sidebar-title">%v</div>`, sidebarLink(root, "index.html", "Project")))
+	sb.WriteString("<ul>")
+	writeSidebarNode(&sb, root)
+	sb.WriteString("</ul>")
+
+	tree, err := json.Marshal(sb.String())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outFolder, "sidebar.js"), []byte(fmt.Sprintf(sidebarScript, tree)), 0777)
+}
+
+// generateSidebarHTML generates the sidebar of the page at `currentPage`, relative
+// to the coverage folder e.g. `dir_1/file_1.html`, which is filled by `sidebar.js`
+//
+// The folders of the current page are expanded and the current page is highlighted
+func generateSidebarHTML(currentPage string) string {
+	currentDir := path.Dir(currentPage)
+	if currentDir == "." {
+		currentDir = ""
+	}
+	rootLink := relativeLink(currentDir, "")
+	if rootLink == "." {
+		rootLink = ""
+	} else {
+		rootLink += "/"
+	}
+
+	return fmt.Sprintf(
+		`<nav id="sidebar" data-root="%v" data-page="%v"></nav><script src="%vsidebar.js"></script>%v`,
+		rootLink, currentPage, rootLink, sidebarCSS,
+	)
+}
+
+func writeSidebarNode(sb *strings.Builder, node *sidebarNode) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		child := node.children[name]
+		sb.WriteString(fmt.Sprintf(`<li><details data-path="%v"><summary>%v</summary><ul>`, child.path, sidebarLink(child, "index.html", child.name+"/")))
+		writeSidebarNode(sb, child)
+		sb.WriteString("</ul></details></li>")
+	}
+
+	if node.folder == nil {
+		return
+	}
+	files := make([]ReportFile, len(node.folder.Files))
+	copy(files, node.folder.Files)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	for _, file := range files {
+		base := path.Base(file.Name)
+		page := strings.Replace(base, ".go", ".html", 1)
+		sb.WriteString(fmt.Sprintf(`<li class="sidebar-file">%v</li>`, sidebarPageLink(path.Join(node.path, page), base, file.Coverage)))
+	}
+}
+
+// sidebarLink returns the link of the page of the node with its coverage badge,
+// or only the label if the folder has no page
+func sidebarLink(node *sidebarNode, page string, label string) string {
+	if node.folder == nil {
+		return label
+	}
+	return sidebarPageLink(path.Join(node.path, page), label, node.folder.GetCoverage().Total)
+}
+
+func sidebarPageLink(target string, label string, coverage float64) string {
+	return fmt.Sprintf(`<a data-page="%v">%v</a> <span class="badge coverage-%v">%.0f%%</span>`, target, label, getCoverageClass(coverage), coverage)
+}
+
+// relativeLink returns the link of the target page from the pages of the folder,
+// both relative to the coverage folder
+func relativeLink(fromDir string, target string) string {
+	rel, err := filepath.Rel(filepath.FromSlash("/"+fromDir), filepath.FromSlash("/"+target))
+	if err != nil {
+		return target
+	}
+	return filepath.ToSlash(rel)
+}
+
+// sidebarScript fills the sidebar of the page with the tree, resolves its links
+// and expands the folders of the current page
+const sidebarScript = `(function () {
+	var nav = document.getElementById("sidebar");
+	if (!nav) return;
+	var root = nav.getAttribute("data-root");
+	var current = nav.getAttribute("data-page");
+	var currentDir = current.indexOf("/") === -1 ? "" : current.substring(0, current.lastIndexOf("/"));
+
+	nav.innerHTML = %s;
+	nav.querySelectorAll("a[data-page]").forEach(function (a) {
+		var page = a.getAttribute("data-page");
+		a.setAttribute("href", root + page);
+		if (page === current) a.className = "current";
+	});
+	nav.querySelectorAll("details[data-path]").forEach(function (d) {
+		var p = d.getAttribute("data-path");
+		if (currentDir === p || currentDir.indexOf(p + "/") === 0) d.open = true;
+	});
+})();
+`
+
+// The sidebar is fixed on the left side of the page, so the body is shifted
+const sidebarCSS = `
+<style>
+	body {
+		margin-left: 320px;
+	}
+	#sidebar {
+		position: fixed;
+		top: 0; left: 0; bottom: 0;
+		width: 300px;
+		overflow: auto;
+		padding: 10px;
+		box-sizing: border-box;
+		background: rgb(20, 20, 20);
+		border-right: 1px solid rgb(80, 80, 80);
+		font-size: 12px;
+	}
+	#sidebar ul {
+		list-style: none;
+		margin: 0;
+		padding-left: 14px;
+	}
+	#sidebar > ul {
+		padding-left: 0;
+	}
+	#sidebar summary {
+		cursor: pointer;
+		margin: 3px 0;
+	}
+	#sidebar .sidebar-title, #sidebar .sidebar-file {
+		margin: 3px 0;
+	}
+	#sidebar .sidebar-file {
+		padding-left: 14px;
+	}
+	#sidebar a.current {
+		color: white;
+		text-decoration: underline;
+	}
+	#sidebar .badge {
+		color: black;
+		padding: 0 3px;
+		font-size: 10px;
+	}
+</style>
+`
@@ -0,0 +1,112 @@
+package report
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/vieolo/shiraz/utils"
+	"golang.org/x/tools/cover"
+)
+
+// TestProfile is the coverage profile of a single top-level test
+type TestProfile struct {
+	Test utils.TestRef
+	// The path of the `.out` file of the test
+	Path string
+}
+
+// MergeTestProfiles records the tests that executed each block of the profiles
+//
+// The blocks that were not executed by any test are dropped
+func MergeTestProfiles(profiles []TestProfile) (utils.TestCoverage, error) {
+	cov := utils.TestCoverage{
+		Time:  time.Now(),
+		Tests: []utils.TestRef{},
+		Files: map[string][]utils.TestCoverageBlock{},
+	}
+
+	type blockKey struct {
+		file      string
+		startLine int
+		endLine   int
+	}
+	blocks := map[blockKey][]int{}
+
+	for _, tp := range profiles {
+		parsed, err := cover.ParseProfiles(tp.Path)
+		if err != nil {
+			return cov, fmt.Errorf("cannot parse the profile of %v: %v", tp.Test.Name, err)
+		}
+
+		testIndex := len(cov.Tests)
+		cov.Tests = append(cov.Tests, tp.Test)
+		for _, p := range parsed {
+			for _, b := range p.Blocks {
+				if b.Count == 0 {
+					continue
+				}
+				key := blockKey{file: p.FileName, startLine: b.StartLine, endLine: b.EndLine}
+				tests := blocks[key]
+				if len(tests) == 0 || tests[len(tests)-1] != testIndex {
+					blocks[key] = append(tests, testIndex)
+				}
+			}
+		}
+	}
+
+	for key, tests := range blocks {
+		cov.Files[key.file] = append(cov.Files[key.file], utils.TestCoverageBlock{
+			StartLine: key.startLine,
+			EndLine:   key.endLine,
+			Tests:     tests,
+		})
+	}
+	for _, fileBlocks := range cov.Files {
+		sort.Slice(fileBlocks, func(i, j int) bool {
+			if fileBlocks[i].StartLine != fileBlocks[j].StartLine {
+				return fileBlocks[i].StartLine < fileBlocks[j].StartLine
+			}
+			return fileBlocks[i].EndLine < fileBlocks[j].EndLine
+		})
+	}
+	return cov, nil
+}
+
+// AttachTestCoverage sets the tests that executed each line of the files of the folders
+func AttachTestCoverage(folders []ReportFolder, cov utils.TestCoverage) {
+	for i := range folders {
+		for j := range folders[i].Files {
+			file := &folders[i].Files[j]
+			file.LineTests = lineTests(cov, file.Name)
+		}
+	}
+}
+
+// lineTests returns the names of the tests that executed each line of the file,
+// sorted by name. The tests are prefixed with the name of their package
+func lineTests(cov utils.TestCoverage, fileName string) map[int][]string {
+	lines := map[int][]string{}
+	seen := map[int]map[int]bool{}
+	for _, b := range cov.Files[fileName] {
+		for l := b.StartLine; l <= b.EndLine; l++ {
+			if seen[l] == nil {
+				seen[l] = map[int]bool{}
+			}
+			for _, t := range b.Tests {
+				if seen[l][t] {
+					continue
+				}
+				seen[l][t] = true
+				ref := cov.Tests[t]
+				lines[l] = append(lines[l], fmt.Sprintf("%v.%v", path.Base(ref.Package), ref.Name))
+			}
+		}
+	}
+
+	for _, tests := range lines {
+		sort.Strings(tests)
+	}
+	return lines
+}
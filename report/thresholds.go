@@ -0,0 +1,142 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/vieolo/shiraz/utils"
+	terminalutils "github.com/vieolo/terminal-utils"
+)
+
+// ThresholdViolation is a coverage that is below its minimum in the config
+type ThresholdViolation struct {
+	Scope    string
+	Name     string
+	Coverage float64
+	Minimum  float64
+}
+
+// CheckThresholds compares the coverage of the project, the folders and the files
+// with the minimums of the `coverage.thresholds` config
+//
+// The folders and the files can be identified either by their package path
+// (like the `ignore` config) or by their path relative to the working directory
+func CheckThresholds(folders []ReportFolder, conf utils.ShirazConfig) []ThresholdViolation {
+	thresholds := conf.Coverage.Thresholds
+	violations := []ThresholdViolation{}
+
+	if thresholds.Global > 0 {
+		total := ProjectCoverage(folders)
+		if total < thresholds.Global {
+			violations = append(violations, ThresholdViolation{Scope: "Project", Name: "total", Coverage: total, Minimum: thresholds.Global})
+		}
+	}
+
+	folderNames := sortedKeys(thresholds.Folders)
+	for _, name := range folderNames {
+		minimum := thresholds.Folders[name]
+		for _, fol := range folders {
+			if !folderMatches(fol, name) {
+				continue
+			}
+			cov := fol.GetCoverage().Total
+			if cov < minimum {
+				violations = append(violations, ThresholdViolation{Scope: "Folder", Name: name, Coverage: cov, Minimum: minimum})
+			}
+		}
+	}
+
+	fileNames := sortedKeys(thresholds.Files)
+	for _, name := range fileNames {
+		minimum := thresholds.Files[name]
+		for _, fol := range folders {
+			for _, file := range fol.Files {
+				if !fileMatches(fol, file, name) {
+					continue
+				}
+				if file.Coverage < minimum {
+					violations = append(violations, ThresholdViolation{Scope: "File", Name: name, Coverage: file.Coverage, Minimum: minimum})
+				}
+			}
+		}
+	}
+
+	return violations
+}
+
+// PrintViolations prints the coverages that are below their minimums as a table
+func PrintViolations(violations []ThresholdViolation) {
+	if len(violations) == 0 {
+		terminalutils.PrintSuccess("All the coverage thresholds are met")
+		return
+	}
+
+	terminalutils.PrintError(fmt.Sprintf("%v coverage threshold(s) are not met", len(violations)))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Scope\tName\tCoverage\tMinimum")
+	for _, v := range violations {
+		fmt.Fprintf(w, "%v\t%v\t%.2f%%\t%.2f%%\n", v.Scope, v.Name, v.Coverage, v.Minimum)
+	}
+	w.Flush()
+}
+
+// folderMatches reports whether the folder is identified by the given name
+func folderMatches(fol ReportFolder, name string) bool {
+	name = path.Clean(name)
+	if fol.RelativePath == name || (name == "." && fol.RelativePath == "") {
+		return true
+	}
+	return len(fol.Files) > 0 && path.Dir(fol.Files[0].Name) == name
+}
+
+// fileMatches reports whether the file of the folder is identified by the given name
+func fileMatches(fol ReportFolder, file ReportFile, name string) bool {
+	return file.Name == name || path.Join(fol.RelativePath, path.Base(file.Name)) == path.Clean(name)
+}
+
+// hasThresholds reports whether any coverage threshold is configured
+func hasThresholds(conf utils.ShirazConfig) bool {
+	t := conf.Coverage.Thresholds
+	return t.Global > 0 || len(t.Folders) > 0 || len(t.Files) > 0
+}
+
+// folderMinimum returns the minimum coverage of the folder, which is its threshold
+// in the config, or the global threshold, or the minimum of the top coverage band
+// if no threshold is configured
+func folderMinimum(conf utils.ShirazConfig, fol ReportFolder) float64 {
+	for _, name := range sortedKeys(conf.Coverage.Thresholds.Folders) {
+		if folderMatches(fol, name) {
+			return conf.Coverage.Thresholds.Folders[name]
+		}
+	}
+	return defaultMinimum(conf)
+}
+
+// fileMinimum returns the minimum coverage of the file of the folder, the same as `folderMinimum`
+func fileMinimum(conf utils.ShirazConfig, fol ReportFolder, file ReportFile) float64 {
+	for _, name := range sortedKeys(conf.Coverage.Thresholds.Files) {
+		if fileMatches(fol, file, name) {
+			return conf.Coverage.Thresholds.Files[name]
+		}
+	}
+	return defaultMinimum(conf)
+}
+
+func defaultMinimum(conf utils.ShirazConfig) float64 {
+	if hasThresholds(conf) {
+		return conf.Coverage.Thresholds.Global
+	}
+	return topBandMin()
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -13,11 +13,73 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 
+	"github.com/vieolo/shiraz/utils"
+	"golang.org/x/exp/slices"
 	"golang.org/x/tools/cover"
 )
 
+// isIgnored reports whether the file, or its folder, is in the `ignore` list of the config
+func isIgnored(fileName string, conf utils.ShirazConfig) bool {
+	if slices.Contains(conf.IgnoreFiles, fileName) {
+		return true
+	}
+
+	sp := strings.Split(fileName, "/")
+	folN := strings.Join(sp[:len(sp)-1], "/")
+
+	return slices.Contains(conf.IgnoreFolders, folN)
+}
+
+// loadProfiles parses the `.out` file, skips the ignored files and finds the packages of the files
+func loadProfiles(outPath string, conf utils.ShirazConfig) ([]*cover.Profile, map[string]*Pkg, error) {
+	profiles, err := cover.ParseProfiles(outPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filtered := make([]*cover.Profile, 0, len(profiles))
+	for _, p := range profiles {
+		if !isIgnored(p.FileName, conf) {
+			filtered = append(filtered, p)
+		}
+	}
+
+	dirs, err := findPkgs(filtered)
+	if err != nil {
+		return nil, nil, err
+	}
+	return filtered, dirs, nil
+}
+
+type lineHit struct {
+	Number int
+	Hits   int
+}
+
+// profileLines returns the hits of each line of the profile, sorted by the line number
+//
+// A line that is shared by multiple blocks gets the highest count of them
+func profileLines(profile *cover.Profile) []lineHit {
+	hits := map[int]int{}
+	for _, b := range profile.Blocks {
+		for l := b.StartLine; l <= b.EndLine; l++ {
+			if c, ok := hits[l]; !ok || b.Count > c {
+				hits[l] = b.Count
+			}
+		}
+	}
+
+	lines := make([]lineHit, 0, len(hits))
+	for number, count := range hits {
+		lines = append(lines, lineHit{Number: number, Hits: count})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Number < lines[j].Number })
+	return lines
+}
+
 func findOrCreateFolder(folders []ReportFolder, relativePath string, absolutePath string) (ReportFolder, bool, int) {
 	for i, f := range folders {
 		if f.RelativePath == relativePath {
@@ -101,8 +163,24 @@ func findFile(pkgs map[string]*Pkg, file string) (string, error) {
 // source code, and tokens, and writes it to the given Writer.
 func htmlGen(w io.Writer, src []byte, boundaries []cover.Boundary) error {
 	dst := bufio.NewWriter(w)
+
+	// The syntax spans are nested in the coverage spans. They are closed and reopened
+	// around the coverage boundaries and the new lines so the spans never overlap
+	tokens := syntaxTokens(src)
+	syntaxOpen := false
+	openSyntax := func() { fmt.Fprintf(dst, `<span class="%v">`, tokens[0].class) }
+
 	for i := range src {
+		if syntaxOpen && tokens[0].end == i {
+			dst.WriteString("</span>")
+			syntaxOpen = false
+			tokens = tokens[1:]
+		}
+
 		for len(boundaries) > 0 && boundaries[0].Offset == i {
+			if syntaxOpen {
+				dst.WriteString("</span>")
+			}
 			b := boundaries[0]
 			if b.Start {
 				n := 0
@@ -114,7 +192,22 @@ func htmlGen(w io.Writer, src []byte, boundaries []cover.Boundary) error {
 				dst.WriteString("</span>")
 			}
 			boundaries = boundaries[1:]
+			if syntaxOpen {
+				openSyntax()
+			}
 		}
+
+		if !syntaxOpen && len(tokens) > 0 && tokens[0].start == i {
+			openSyntax()
+			syntaxOpen = true
+		}
+
+		if syntaxOpen && src[i] == '\n' {
+			dst.WriteString("</span>\n")
+			openSyntax()
+			continue
+		}
+
 		switch b := src[i]; b {
 		case '>':
 			dst.WriteString("&gt;")
@@ -128,6 +221,9 @@ func htmlGen(w io.Writer, src []byte, boundaries []cover.Boundary) error {
 			dst.WriteByte(b)
 		}
 	}
+	if syntaxOpen {
+		dst.WriteString("</span>")
+	}
 	return dst.Flush()
 }
 
@@ -171,19 +267,6 @@ func getFolderPath(p string) (string, string, string) {
 	return j, absPath, sp[len(sp)-1]
 }
 
-func getCoverageClass(cov float64) string {
-	coverageClass := "success"
-	if cov == -1 {
-		coverageClass = "none"
-	} else if cov > 30 && cov < 80 {
-		coverageClass = "alert"
-	} else if cov <= 30 {
-		coverageClass = "error"
-	}
-
-	return coverageClass
-}
-
 func getSubfolders(currentFolder ReportFolder, allFolders []ReportFolder) []ReportFolder {
 	subs := make([]ReportFolder, 0)
 	mainDivCount := len(strings.Split(currentFolder.AbsolutePath, "/"))
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"bufio"
+	"io"
+	"os/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vieolo/shiraz/output"
+)
+
+// eventMsg carries a single `test2json` event of the running command
+type eventMsg struct {
+	event output.TestEvent
+}
+
+// errLineMsg carries a line of the stderr of the running command (e.g. build errors)
+type errLineMsg struct {
+	line string
+}
+
+// doneMsg is sent once the running command exits
+type doneMsg struct {
+	err error
+}
+
+// startRun runs the test command in the background and sends its messages to the channel
+func startRun(args []string, ch chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		c := exec.Command(args[0], args[1:]...)
+		stdout, pipeErr := c.StdoutPipe()
+		if pipeErr != nil {
+			return doneMsg{err: pipeErr}
+		}
+		stderr, pipeErr := c.StderrPipe()
+		if pipeErr != nil {
+			return doneMsg{err: pipeErr}
+		}
+
+		if startErr := c.Start(); startErr != nil {
+			return doneMsg{err: startErr}
+		}
+
+		go func() {
+			errDone := make(chan bool)
+			go func() {
+				readLines(stderr, func(l string) { ch <- errLineMsg{line: l} })
+				errDone <- true
+			}()
+
+			output.ReadEvents(stdout, func(e output.TestEvent) { ch <- eventMsg{event: e} })
+			<-errDone
+
+			// A failing test exits with a non-zero code as well, the failures
+			// are already shown from the events
+			c.Wait()
+			ch <- doneMsg{}
+		}()
+
+		return waitForMsg(ch)()
+	}
+}
+
+// waitForMsg waits for the next message of the running command
+func waitForMsg(ch chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+func readLines(r io.Reader, handle func(l string)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		handle(scanner.Text())
+	}
+}
+
+// rerunArgs builds the command that re-runs a single package, or a single test of it
+func rerunArgs(command string, pkg string, test string) []string {
+	runPattern := ""
+	if test != "" {
+		runPattern = output.RunPattern([]string{test})
+	}
+	return append(output.TargetCommand(command, []string{pkg}, runPattern), "-count=1")
+}
@@ -0,0 +1,279 @@
+// Package tui provides the interactive terminal UI of the `test` command
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/vieolo/shiraz/output"
+)
+
+const (
+	statusRunning = "run"
+	statusPass    = "pass"
+	statusFail    = "fail"
+	statusSkip    = "skip"
+)
+
+type testItem struct {
+	name    string
+	status  string
+	elapsed float64
+	output  []string
+}
+
+type packageItem struct {
+	name     string
+	status   string
+	elapsed  float64
+	expanded bool
+	tests    []*testItem
+	output   []string
+}
+
+// row is a visible line of the list, which is either a package or one of its tests
+type row struct {
+	pkg  *packageItem
+	test *testItem
+}
+
+type model struct {
+	command  string
+	packages []*packageItem
+	stderr   []string
+	builds   map[string][]string
+	cursor   int
+	running  bool
+	msgs     chan tea.Msg
+	spinner  spinner.Model
+	detail   viewport.Model
+	width    int
+	height   int
+}
+
+// Run starts the interactive UI and runs the given test command, which must use `-json`
+func Run(command string) error {
+	m := model{
+		command:  command,
+		packages: []*packageItem{},
+		builds:   map[string][]string{},
+		running:  true,
+		msgs:     make(chan tea.Msg),
+		spinner:  spinner.New(spinner.WithSpinner(spinner.Dot)),
+		detail:   viewport.New(0, 0),
+	}
+
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, startRun(strings.Fields(m.command), m.msgs))
+}
+
+func (m *model) run(args []string) tea.Cmd {
+	m.running = true
+	return startRun(args, m.msgs)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.detail.Width = m.detailWidth()
+		m.detail.Height = m.height - 4
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.rows())-1 {
+				m.cursor++
+			}
+		case "enter", " ":
+			if r, ok := m.selected(); ok && r.test == nil {
+				r.pkg.expanded = !r.pkg.expanded
+			}
+		case "pgdown":
+			m.detail.HalfViewDown()
+		case "pgup":
+			m.detail.HalfViewUp()
+		case "r":
+			if r, ok := m.selected(); ok && !m.running {
+				test := ""
+				if r.test != nil {
+					test = r.test.name
+				}
+				m.reset(r.pkg, test)
+				return m, m.run(rerunArgs(m.command, r.pkg.name, test))
+			}
+		case "R":
+			if !m.running {
+				m.packages = []*packageItem{}
+				m.stderr = []string{}
+				m.cursor = 0
+				return m, m.run(strings.Fields(m.command))
+			}
+		}
+
+	case eventMsg:
+		m.handleEvent(msg.event)
+		m.refreshDetail()
+		return m, waitForMsg(m.msgs)
+
+	case errLineMsg:
+		m.stderr = append(m.stderr, msg.line)
+		m.refreshDetail()
+		return m, waitForMsg(m.msgs)
+
+	case doneMsg:
+		m.running = false
+		if msg.err != nil {
+			m.stderr = append(m.stderr, msg.err.Error())
+		}
+		// Packages without a final event (e.g. a build failure) are not running anymore
+		// and the tests that were reset but not re-run are removed
+		for _, p := range m.packages {
+			if p.status == statusRunning {
+				p.status = statusFail
+			}
+			tests := []*testItem{}
+			for _, t := range p.tests {
+				if t.status != statusRunning {
+					tests = append(tests, t)
+				}
+			}
+			p.tests = tests
+		}
+		if m.cursor >= len(m.rows()) {
+			m.cursor = len(m.rows()) - 1
+		}
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
+	m.refreshDetail()
+	return m, nil
+}
+
+func (m *model) handleEvent(e output.TestEvent) {
+	if e.Action == "build-output" {
+		m.builds[e.ImportPath] = append(m.builds[e.ImportPath], strings.TrimSuffix(e.Output, "\n"))
+		return
+	}
+	if e.Package == "" {
+		return
+	}
+	pkg := m.findPackage(e.Package)
+
+	if e.Test == "" {
+		switch e.Action {
+		case "start", "run":
+			pkg.status = statusRunning
+		case "output":
+			pkg.output = append(pkg.output, strings.TrimSuffix(e.Output, "\n"))
+		case statusPass, statusFail, statusSkip:
+			pkg.status = e.Action
+			pkg.elapsed = e.Elapsed
+			if e.FailedBuild != "" {
+				pkg.output = append(pkg.output, m.builds[e.FailedBuild]...)
+				delete(m.builds, e.FailedBuild)
+			}
+
+			// Failing packages are expanded so the failing tests are visible right away
+			if e.Action == statusFail {
+				pkg.expanded = true
+			}
+		}
+		return
+	}
+
+	test := pkg.findTest(e.Test)
+	switch e.Action {
+	case "run":
+		test.status = statusRunning
+	case "output":
+		test.output = append(test.output, strings.TrimSuffix(e.Output, "\n"))
+	case statusPass, statusFail, statusSkip:
+		test.status = e.Action
+		test.elapsed = e.Elapsed
+	}
+}
+
+// reset clears the results of a package, or of a single test and its subtests, before a re-run
+func (m *model) reset(pkg *packageItem, test string) {
+	pkg.status = statusRunning
+	pkg.output = []string{}
+	m.stderr = []string{}
+
+	if test == "" {
+		pkg.tests = []*testItem{}
+		if m.cursor >= len(m.rows()) {
+			m.cursor = len(m.rows()) - 1
+		}
+		return
+	}
+
+	for _, t := range pkg.tests {
+		if t.name == test || strings.HasPrefix(t.name, test+"/") {
+			t.status = statusRunning
+			t.elapsed = 0
+			t.output = []string{}
+		}
+	}
+}
+
+func (m *model) findPackage(name string) *packageItem {
+	for _, p := range m.packages {
+		if p.name == name {
+			return p
+		}
+	}
+	p := &packageItem{name: name, status: statusRunning, tests: []*testItem{}}
+	m.packages = append(m.packages, p)
+	return p
+}
+
+func (p *packageItem) findTest(name string) *testItem {
+	for _, t := range p.tests {
+		if t.name == name {
+			return t
+		}
+	}
+	t := &testItem{name: name, status: statusRunning}
+	p.tests = append(p.tests, t)
+	return t
+}
+
+func (m model) rows() []row {
+	rows := []row{}
+	for _, p := range m.packages {
+		rows = append(rows, row{pkg: p})
+		if p.expanded {
+			for _, t := range p.tests {
+				rows = append(rows, row{pkg: p, test: t})
+			}
+		}
+	}
+	return rows
+}
+
+func (m model) selected() (row, bool) {
+	rows := m.rows()
+	if m.cursor < 0 || m.cursor >= len(rows) {
+		return row{}, false
+	}
+	return rows[m.cursor], true
+}
@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/vieolo/shiraz/output"
+)
+
+var (
+	passStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	failStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	skipStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	selectedStyle = lipgloss.NewStyle().Bold(true).Reverse(true)
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8"))
+)
+
+func (m model) View() string {
+	if m.width == 0 {
+		return "Starting..."
+	}
+
+	list := paneStyle.Width(m.listWidth()).Height(m.height - 4).Render(m.listView())
+	detail := paneStyle.Width(m.detailWidth()).Height(m.height - 4).Render(m.detail.View())
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, list, detail),
+		m.helpView(),
+	)
+}
+
+func (m model) listWidth() int {
+	return m.width*2/5 - 2
+}
+
+func (m model) detailWidth() int {
+	return m.width - m.listWidth() - 4
+}
+
+func (m model) listView() string {
+	rows := m.rows()
+	height := m.height - 4
+
+	// Keeping the cursor in the visible part of the list
+	start := 0
+	if m.cursor >= height {
+		start = m.cursor - height + 1
+	}
+
+	lines := []string{}
+	for i := start; i < len(rows) && i < start+height; i++ {
+		r := rows[i]
+		var line string
+		if r.test == nil {
+			arrow := "▸"
+			if r.pkg.expanded {
+				arrow = "▾"
+			}
+			line = fmt.Sprintf("%v %v %v %v", m.statusIcon(r.pkg.status), arrow, r.pkg.name, dimStyle.Render(elapsedText(r.pkg.status, r.pkg.elapsed)))
+		} else {
+			depth := strings.Count(r.test.name, "/")
+			name := r.test.name[strings.LastIndex(r.test.name, "/")+1:]
+			line = fmt.Sprintf("%v%v %v %v", strings.Repeat("  ", depth+2), m.statusIcon(r.test.status), name, dimStyle.Render(elapsedText(r.test.status, r.test.elapsed)))
+		}
+
+		if i == m.cursor {
+			line = selectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m model) statusIcon(status string) string {
+	switch status {
+	case statusPass:
+		return passStyle.Render("✓")
+	case statusFail:
+		return failStyle.Render("x")
+	case statusSkip:
+		return skipStyle.Render("-")
+	}
+	return m.spinner.View()
+}
+
+func elapsedText(status string, elapsed float64) string {
+	if status == statusRunning {
+		return ""
+	}
+	return fmt.Sprintf("%.2fs", elapsed)
+}
+
+func (m model) helpView() string {
+	state := "done"
+	if m.running {
+		state = "running"
+	}
+	return dimStyle.Render(fmt.Sprintf(" %v • ↑/↓ move • enter expand • r re-run selected • R re-run all • pgup/pgdown scroll • q quit", state))
+}
+
+// refreshDetail updates the detail pane with the output of the selected package or test
+func (m *model) refreshDetail() {
+	r, ok := m.selected()
+	if !ok {
+		m.detail.SetContent(strings.Join(m.stderr, "\n"))
+		return
+	}
+
+	lines := []string{}
+	if r.test == nil {
+		lines = append(lines, lipgloss.NewStyle().Bold(true).Render(r.pkg.name), "")
+		failed := []string{}
+		for _, t := range r.pkg.tests {
+			if t.status == statusFail {
+				failed = append(failed, failStyle.Render("x ")+t.name)
+			}
+		}
+		if len(failed) > 0 {
+			lines = append(lines, "Failed tests")
+			lines = append(lines, failed...)
+			lines = append(lines, "")
+		}
+		lines = append(lines, r.pkg.output...)
+		lines = append(lines, m.stderr...)
+	} else {
+		lines = append(lines, lipgloss.NewStyle().Bold(true).Render(r.test.name), "")
+		for _, t := range output.ParseTraces(r.test.output) {
+			lines = append(lines,
+				failStyle.Render(fmt.Sprintf("%v -> %v", t.TestName, t.ErrorName)),
+				skipStyle.Render(fmt.Sprintf("  Expected  %v", t.Expected)),
+				failStyle.Render(fmt.Sprintf("  Actual    %v", t.Actual)),
+				fmt.Sprintf("  File      %v", t.FileName),
+				fmt.Sprintf("  Line      %v", t.LineNumber),
+				"",
+			)
+		}
+		lines = append(lines, r.test.output...)
+	}
+
+	m.detail.SetContent(lipgloss.NewStyle().Width(m.detail.Width).Render(strings.Join(lines, "\n")))
+}
@@ -9,10 +9,62 @@ import (
 type testConifg struct {
 	Command string `json:"command"`
 	Output  string `json:"output"`
+	Retries int    `json:"retries"`
+	JUnit   string `json:"junit"`
+}
+
+type thresholdsConfig struct {
+	Global  float64            `json:"global"`
+	Folders map[string]float64 `json:"folders"`
+	Files   map[string]float64 `json:"files"`
+}
+
+// The aggregation modes of the coverage of the folders and the project
+const (
+	// The ratio of the covered statements to the total statements (default)
+	AggregationStatements = "statements"
+	// The average of the coverage percentages of the files
+	AggregationAverage = "average"
+)
+
+// CoverageBand is a named range of coverage with its own color in the reports
+//
+// A coverage belongs to the band with the highest `Min` that is not above it
+type CoverageBand struct {
+	Name string `json:"name"`
+	// The minimum coverage of the band in percent
+	Min float64 `json:"min"`
+	// A CSS color, e.g. `#39dc39` or `rgb(57, 220, 57)`
+	Color string `json:"color"`
+}
+
+// DefaultCoverageBands returns the bands used when no band is defined in the config
+func DefaultCoverageBands() []CoverageBand {
+	return []CoverageBand{
+		{Name: "error", Min: 0, Color: "rgb(229, 85, 85)"},
+		{Name: "alert", Min: 30, Color: "rgb(220, 207, 104)"},
+		{Name: "success", Min: 80, Color: "rgb(57, 220, 57)"},
+	}
+}
+
+type coverageConfig struct {
+	Thresholds  thresholdsConfig `json:"thresholds"`
+	Aggregation string           `json:"aggregation"`
+	Bands       []CoverageBand   `json:"bands"`
+}
+
+type reportConfig struct {
+	// Prints the coverage in the terminal without generating the HTML files
+	// or opening the browser
+	TerminalOnly bool `json:"terminalOnly"`
+	// The `-covermode` of the tests, one of `set`, `count` and `atomic`
+	CoverMode string `json:"coverMode"`
 }
 
 type ShirazConfig struct {
 	Test               testConifg        `json:"test"`
+	Report             reportConfig      `json:"report"`
+	Coverage           coverageConfig    `json:"coverage"`
 	ProjectPath        string            `json:"projectPath"`
 	CoverageFolderPath string            `json:"coverageFolderPath"`
 	Env                map[string]string `json:"env"`
@@ -57,9 +109,12 @@ func GetConfig() (ShirazConfig, error) {
 func GetDefaultConfig() ShirazConfig {
 	return ShirazConfig{
 		Test: testConifg{
-			Command: "go test -v ./...",
+			Command: "go test -json ./...",
 			Output:  "pkgname",
 		},
+		Coverage: coverageConfig{
+			Bands: DefaultCoverageBands(),
+		},
 		ProjectPath:        ".",
 		CoverageFolderPath: "./coverage/",
 		Ignore:             make([]string, 0),
@@ -90,5 +145,9 @@ func GetConfigOrDefault() ShirazConfig {
 		userDefined.Test.Output = defaultConf.Test.Output
 	}
 
+	if len(userDefined.Coverage.Bands) == 0 {
+		userDefined.Coverage.Bands = defaultConf.Coverage.Bands
+	}
+
 	return userDefined
 }
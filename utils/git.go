@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DiffHunk is a changed part of a file
+type DiffHunk struct {
+	OldStart int
+	OldCount int
+	NewStart int
+	NewCount int
+	// The removed (`-`) and the added (`+`) lines of the hunk, with their prefix
+	Lines []string
+}
+
+// FileDiff is the change of a single file
+type FileDiff struct {
+	// The file is deleted, its path is the path of the old version
+	Removed bool
+	Hunks   []DiffHunk
+}
+
+// AddedLines returns the added and the modified lines of the new version of the file
+func (d FileDiff) AddedLines() []int {
+	lines := []int{}
+	for _, h := range d.Hunks {
+		for i := 0; i < h.NewCount; i++ {
+			lines = append(lines, h.NewStart+i)
+		}
+	}
+	return lines
+}
+
+var hunkRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// GitDiff returns the changes of the Go files since the merge base of the base ref,
+// keyed by the absolute path of the files
+//
+// The uncommitted changes of the working tree are included, as well as the untracked
+// files that are not ignored
+func GitDiff(base string) (map[string]FileDiff, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	mergeBase, err := GitMergeBase(base)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := gitOutput("diff", "--unified=0", "--no-color", "--no-ext-diff", "--no-renames", mergeBase, "--", "*.go")
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := map[string]FileDiff{}
+	oldPath := ""
+	current := ""
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		l := scanner.Text()
+		switch {
+		case strings.HasPrefix(l, "diff --git "):
+			current = ""
+			oldPath = ""
+			continue
+		case strings.HasPrefix(l, "--- ") && current == "":
+			oldPath = strings.TrimPrefix(strings.TrimPrefix(l, "--- "), "a/")
+			continue
+		case strings.HasPrefix(l, "+++ ") && current == "":
+			name := strings.TrimPrefix(l, "+++ ")
+			if name == "/dev/null" {
+				current = filepath.Join(root, oldPath)
+				diffs[current] = FileDiff{Removed: true}
+			} else {
+				current = filepath.Join(root, strings.TrimPrefix(name, "b/"))
+				diffs[current] = FileDiff{}
+			}
+			continue
+		}
+		if current == "" {
+			continue
+		}
+
+		d := diffs[current]
+		if m := hunkRegex.FindStringSubmatch(l); m != nil {
+			d.Hunks = append(d.Hunks, DiffHunk{
+				OldStart: atoi(m[1]),
+				OldCount: hunkCount(m[2]),
+				NewStart: atoi(m[3]),
+				NewCount: hunkCount(m[4]),
+			})
+		} else if len(d.Hunks) > 0 && (strings.HasPrefix(l, "-") || strings.HasPrefix(l, "+")) {
+			last := &d.Hunks[len(d.Hunks)-1]
+			last.Lines = append(last.Lines, l)
+		}
+		diffs[current] = d
+	}
+
+	// The untracked files are not in the diff, so they are added as fully added files
+	untracked, err := gitOutput("ls-files", "--others", "--exclude-standard", "--full-name", "--", "*.go")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(untracked, "\n") {
+		if name == "" {
+			continue
+		}
+		file := filepath.Join(root, name)
+		d, readErr := addedFileDiff(file)
+		if readErr != nil {
+			return nil, readErr
+		}
+		diffs[file] = d
+	}
+	return diffs, nil
+}
+
+// addedFileDiff returns the diff of a new file, with all of its lines as added
+func addedFileDiff(file string) (FileDiff, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return FileDiff{}, err
+	}
+	text := strings.TrimSuffix(string(content), "\n")
+	if text == "" {
+		return FileDiff{}, nil
+	}
+
+	lines := strings.Split(text, "\n")
+	h := DiffHunk{NewStart: 1, NewCount: len(lines), Lines: make([]string, 0, len(lines))}
+	for _, l := range lines {
+		h.Lines = append(h.Lines, "+"+l)
+	}
+	return FileDiff{Hunks: []DiffHunk{h}}, nil
+}
+
+// GitMergeBase returns the commit of the merge base of the ref and HEAD
+func GitMergeBase(ref string) (string, error) {
+	return gitOutput("merge-base", ref, "HEAD")
+}
+
+// GitHead returns the HEAD commit and whether the Go files have uncommitted changes
+func GitHead() (string, bool, error) {
+	head, err := gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		return "", false, err
+	}
+	status, err := gitOutput("status", "--porcelain", "--", "*.go")
+	if err != nil {
+		return "", false, err
+	}
+	return head, status != "", nil
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// hunkCount returns the line count of a hunk range, which is 1 when omitted
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	return atoi(s)
+}
+
+func gitOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot run git %v: %v\n%s", strings.Join(args, " "), err, stderr.Bytes())
+	}
+	return strings.TrimSpace(string(stdout)), nil
+}
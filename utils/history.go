@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FlakyHistorySize is the number of the recent runs kept in the flaky history
+const FlakyHistorySize = 100
+
+// FlakyRun is the record of a single test run with retries
+type FlakyRun struct {
+	Time time.Time `json:"time"`
+	// The import paths of the packages that ran
+	Packages []string `json:"packages"`
+	// The flaky tests of each package
+	Flaky map[string][]string `json:"flaky"`
+}
+
+// FlakyStat is the flake rate of a single test over the recent runs
+type FlakyStat struct {
+	Package    string
+	Test       string
+	FlakyCount int
+	RunCount   int
+	Rate       float64
+}
+
+func flakyHistoryPath() string {
+	return filepath.Join(StateFolderPath, "flaky_history.json")
+}
+
+// GetFlakyHistory reads the recent runs from the history file, the oldest run first
+func GetFlakyHistory() []FlakyRun {
+	runs := []FlakyRun{}
+	b, err := os.ReadFile(flakyHistoryPath())
+	if err != nil {
+		return runs
+	}
+	if json.Unmarshal(b, &runs) != nil {
+		return []FlakyRun{}
+	}
+	return runs
+}
+
+// AddFlakyRun appends the run to the history file and drops the runs older
+// than the last `FlakyHistorySize` runs
+func AddFlakyRun(run FlakyRun) error {
+	runs := append(GetFlakyHistory(), run)
+	if len(runs) > FlakyHistorySize {
+		runs = runs[len(runs)-FlakyHistorySize:]
+	}
+
+	if err := os.MkdirAll(StateFolderPath, 0777); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(runs, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(flakyHistoryPath(), b, 0666)
+}
+
+// GetFlakyStats returns the flake rate of the tests that were flaky at least once in
+// the recent runs, the highest rate first
+//
+// The rate of a test is the number of the runs it was flaky in, divided by the
+// number of the runs that included its package
+func GetFlakyStats(runs []FlakyRun) []FlakyStat {
+	stats := map[string]*FlakyStat{}
+	for _, run := range runs {
+		for pkg, tests := range run.Flaky {
+			for _, test := range tests {
+				key := pkg + "\t" + test
+				if _, ok := stats[key]; !ok {
+					stats[key] = &FlakyStat{Package: pkg, Test: test}
+				}
+				stats[key].FlakyCount += 1
+			}
+		}
+	}
+
+	for _, s := range stats {
+		for _, run := range runs {
+			for _, pkg := range run.Packages {
+				if pkg == s.Package {
+					s.RunCount += 1
+					break
+				}
+			}
+		}
+	}
+
+	list := []FlakyStat{}
+	for _, s := range stats {
+		s.Rate = float64(s.FlakyCount) / float64(s.RunCount) * 100
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Rate == list[j].Rate {
+			return list[i].FlakyCount > list[j].FlakyCount
+		}
+		return list[i].Rate > list[j].Rate
+	})
+	return list
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// StateFolderPath is the folder where Shiraz keeps the data between the runs
+const StateFolderPath = "./.shiraz/"
+
+// TestState is the state of the last test run, which is saved in the state folder
+type TestState struct {
+	// The failed tests of each failed package, keyed by the import path of the package
+	Failed map[string][]string `json:"failed"`
+}
+
+func testStatePath() string {
+	return filepath.Join(StateFolderPath, "test_state.json")
+}
+
+// GetTestState reads the state of the last test run, or returns an empty state
+// if there is no saved state
+func GetTestState() TestState {
+	state := TestState{Failed: map[string][]string{}}
+
+	b, err := os.ReadFile(testStatePath())
+	if err != nil {
+		return state
+	}
+
+	if json.Unmarshal(b, &state) != nil || state.Failed == nil {
+		return TestState{Failed: map[string][]string{}}
+	}
+	return state
+}
+
+// SaveTestState writes the state of the test run in the state folder
+func SaveTestState(state TestState) error {
+	if err := os.MkdirAll(StateFolderPath, 0777); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(testStatePath(), b, 0666)
+}
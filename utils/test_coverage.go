@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TestRef identifies a top-level test of a package
+type TestRef struct {
+	Package string `json:"package"`
+	Name    string `json:"name"`
+}
+
+// TestCoverageBlock is a block of a file with the tests that executed it
+type TestCoverageBlock struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+	// The indexes of the tests in `TestCoverage.Tests`
+	Tests []int `json:"tests"`
+}
+
+// TestCoverage is the coverage of each test from the last `report --per-test` run,
+// which is saved in the state folder
+type TestCoverage struct {
+	Time time.Time `json:"time"`
+	// The HEAD commit of the run, and whether the Go files had uncommitted changes.
+	// The line numbers of the blocks are of that tree
+	Commit string    `json:"commit"`
+	Dirty  bool      `json:"dirty"`
+	Tests  []TestRef `json:"tests"`
+	// The executed blocks of each file, keyed by the file name of the coverage profile
+	Files map[string][]TestCoverageBlock `json:"files"`
+}
+
+func testCoveragePath() string {
+	return filepath.Join(StateFolderPath, "test_coverage.json")
+}
+
+// GetTestCoverage reads the coverage of the tests from the state folder. The
+// boolean is false if there is no saved coverage
+func GetTestCoverage() (TestCoverage, bool) {
+	b, err := os.ReadFile(testCoveragePath())
+	if err != nil {
+		return TestCoverage{}, false
+	}
+
+	var cov TestCoverage
+	if json.Unmarshal(b, &cov) != nil || cov.Files == nil {
+		return TestCoverage{}, false
+	}
+	return cov, true
+}
+
+// SaveTestCoverage writes the coverage of the tests in the state folder, with the
+// commit of the tree when it is a git repository
+func SaveTestCoverage(cov TestCoverage) error {
+	cov.Commit, cov.Dirty, _ = GitHead()
+
+	if err := os.MkdirAll(StateFolderPath, 0777); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(cov)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(testCoveragePath(), b, 0666)
+}
@@ -0,0 +1,126 @@
+package watch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+)
+
+type listPkg struct {
+	ImportPath   string
+	Dir          string
+	Standard     bool
+	DepOnly      bool
+	Deps         []string
+	TestImports  []string
+	XTestImports []string
+}
+
+// AffectedPackages returns the import paths of the packages of the project that
+// contain one of the changed files, plus the packages that import them directly,
+// transitively, or from their tests
+//
+// The import graph is read from `go list -deps -json <projectPath>/...`
+func AffectedPackages(projectPath string, files []string) ([]string, error) {
+	pkgs, err := listPackages(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []string{}
+	for _, pkg := range pkgs {
+		for _, f := range files {
+			if filepath.Dir(f) == pkg.Dir {
+				changed = append(changed, pkg.ImportPath)
+				break
+			}
+		}
+	}
+
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	// The non test dependencies are transitive, so the packages importing
+	// the changed ones are found in a single pass
+	importers := []string{}
+	for _, pkg := range pkgs {
+		if slices.Contains(changed, pkg.ImportPath) || containsAny(pkg.Deps, changed) {
+			importers = append(importers, pkg.ImportPath)
+		}
+	}
+
+	// The imports of the tests are not transitive, so the tests importing
+	// any of the affected packages are added as well
+	affected := []string{}
+	for _, pkg := range pkgs {
+		if pkg.DepOnly {
+			continue
+		}
+		if slices.Contains(importers, pkg.ImportPath) ||
+			containsAny(pkg.TestImports, importers) ||
+			containsAny(pkg.XTestImports, importers) {
+			affected = append(affected, pkg.ImportPath)
+		}
+	}
+
+	return affected, nil
+}
+
+// ProjectPackages returns the import paths of the packages of the project, keyed by their folder
+func ProjectPackages(projectPath string) (map[string]string, error) {
+	pkgs, err := listPackages(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := map[string]string{}
+	for _, pkg := range pkgs {
+		if !pkg.DepOnly {
+			dirs[pkg.Dir] = pkg.ImportPath
+		}
+	}
+	return dirs, nil
+}
+
+func listPackages(projectPath string) ([]listPkg, error) {
+	cmd := exec.Command("go", "list", "-e", "-deps", "-json", fmt.Sprintf("%v/...", projectPath))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot run go list: %v\n%s", err, stderr.Bytes())
+	}
+
+	pkgs := []listPkg{}
+	dec := json.NewDecoder(bytes.NewReader(stdout))
+	for {
+		var pkg listPkg
+		err := dec.Decode(&pkg)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding go list json: %v", err)
+		}
+
+		if pkg.Standard || pkg.Dir == "" {
+			continue
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+func containsAny(list []string, values []string) bool {
+	for _, v := range values {
+		if slices.Contains(list, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+// Package watch re-runs the tests and the reports when the Go files of the project change
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Interval is the time between two scans of the project files
+const Interval = time.Second
+
+// Run scans the `.go` files of the root folder every `Interval` and calls `onChange`
+// with the files that were added, modified or removed since the last scan
+//
+// Run blocks forever
+func Run(root string, onChange func(files []string)) {
+	previous := scan(root)
+	for {
+		time.Sleep(Interval)
+		current := scan(root)
+
+		changed := []string{}
+		for f, mod := range current {
+			if prev, ok := previous[f]; !ok || !prev.Equal(mod) {
+				changed = append(changed, f)
+			}
+		}
+		for f := range previous {
+			if _, ok := current[f]; !ok {
+				changed = append(changed, f)
+			}
+		}
+
+		previous = current
+		if len(changed) > 0 {
+			sort.Strings(changed)
+			onChange(changed)
+		}
+	}
+}
+
+// scan returns the modification time of all the `.go` files of the root folder
+//
+// The hidden folders (e.g. `.git`) and the `vendor` folders are skipped
+func scan(root string) map[string]time.Time {
+	files := map[string]time.Time{}
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+
+		info, infoErr := d.Info()
+		if infoErr != nil {
+			return nil
+		}
+		abs, absErr := filepath.Abs(p)
+		if absErr != nil {
+			abs = p
+		}
+		files[abs] = info.ModTime()
+		return nil
+	})
+	return files
+}
+
+// ClearScreen clears the terminal before the output of a new run
+func ClearScreen() {
+	os.Stdout.WriteString("\033[H\033[2J")
+}